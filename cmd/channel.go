@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+)
+
+// continueChannel returns the next version of the release cycle in progress
+// on releaseChannel: the final release for the final channel, or the next
+// pre-release otherwise. Moving back to a less stable channel needs
+// forceChannel and starts it on the next patch version. It returns nil when
+// no cycle is in progress and a new version has to be selected first.
+func continueChannel(versions []*tagutils.TagInfo) (*tagutils.TagInfo, error) {
+	inProgress := tagutils.InProgress(versions)
+	if inProgress == nil {
+		if releaseChannel == tagutils.ChannelFinal {
+			return nil, fmt.Errorf("there is no pre-release to promote to final")
+		}
+		return nil, nil
+	}
+
+	color.Cyan("Pre-release in progress: %s", inProgress.Version)
+	if releaseChannel == tagutils.ChannelFinal {
+		return inProgress.Final(), nil
+	}
+	if tagutils.ChannelRank(releaseChannel) >= tagutils.ChannelRank(inProgress.Channel) {
+		return tagutils.NextPrerelease(versions, inProgress, releaseChannel), nil
+	}
+
+	// A less stable pre-release of the same version would sort below the one
+	// in progress, so Go would never select it
+	if !forceChannel {
+		return nil, fmt.Errorf("%s is already on the %s channel; moving back to %s needs --force", inProgress.Version, inProgress.Channel, releaseChannel)
+	}
+	next, err := tagutils.CalculateNewVersion(inProgress.Final(), "patch")
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate new version: %w", err)
+	}
+	color.Yellow("Warning: moving back to the %s channel on %s, since a %s pre-release of %s would sort below %s",
+		releaseChannel, next.Version, releaseChannel, inProgress.Final().Version, inProgress.Version)
+	return tagutils.NextPrerelease(versions, next, releaseChannel), nil
+}
+
+// startChannel turns the version selected for a new release cycle into its
+// first pre-release on releaseChannel. Explicit pre-releases are kept.
+func startChannel(versions []*tagutils.TagInfo, version *tagutils.TagInfo) *tagutils.TagInfo {
+	if releaseChannel == "" || releaseChannel == tagutils.ChannelFinal || version.IsPrerelease() {
+		return version
+	}
+	return tagutils.NextPrerelease(versions, version, releaseChannel)
+}
@@ -1,84 +1,675 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
+	"sort"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/display"
+	"github.com/gambitier/tag-manager/pkg/interactive"
 	"github.com/spf13/cobra"
 )
 
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Show current configuration",
-	Long:  `Display the current tag-manager configuration including file location and package settings.`,
-	RunE:  runConfig,
+	Long: `Display the effective tag-manager configuration, the files it was loaded from
+and which layer each value came from.
+
+Layers are merged with the following precedence (highest first):
+  flag      --tag-format and --remote
+  env       TAG_MANAGER_TAG_FORMAT and TAG_MANAGER_REMOTES
+  repo      .tag-manager.yaml at the repository root
+  global    ~/.tag-manager.yaml (or TAG_MANAGER_CONFIG)
+  built-in  default values`,
+	RunE: runConfig,
 }
 
-func runConfig(cmd *cobra.Command, args []string) error {
-	// Get config path
-	configPath := config.GetConfigPath()
+var (
+	configRepo   bool
+	configDryRun bool
+	configOutput string
+)
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [<package>|defaults] [<key>]",
+	Short: "Show effective configuration values",
+	Long: `Show effective configuration values and the layer each came from.
+Without arguments all default and package settings are shown.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <package>|defaults <key> <value>",
+	Short: "Change a configuration value",
+	Long: fmt.Sprintf(`Change a configuration value of a package (module path or package name) or of the defaults.
+
+Package keys: %s
+Default keys: %s
+
+bump is the policy choosing the version type of releases: ask, api (the smallest
+type allowed by the API check), patch, minor or major. tag_message is the annotation
+of created tags, release_branch the branch released commits must be reachable from,
+and version_scheme semver, calver or calver:LAYOUT (e.g. calver:YY.0M.DD).
+
+Lists such as remotes are comma separated. Values are written to the global config
+file, or to the repository's .tag-manager.yaml with --repo.`, strings.Join(config.PackageKeys, ", "), strings.Join(config.DefaultKeys, ", ")),
+	Args: cobra.ExactArgs(3),
+	RunE: runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <package>|defaults [<key>]",
+	Short: "Remove a configuration value or package entry",
+	Long:  `Remove a configuration value, or the whole package entry when no key is given.`,
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  runConfigUnset,
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the configuration file in $EDITOR",
+	Long:  `Open the global config file (or the repository file with --repo) in $VISUAL or $EDITOR and validate it after saving.`,
+	Args:  cobra.NoArgs,
+	RunE:  runConfigEdit,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the configuration files",
+	Long: `Check the global and repository config files for unknown keys and invalid tag formats,
+and the effective configuration for packages sharing a tag prefix.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigValidate,
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the configuration file to the current schema",
+	Long: `Upgrade the global config file (or the repository file with --repo) to the current
+schema version. The original file is kept as a backup next to it.
+
+The global file is also migrated automatically when it is loaded. Use --dry-run to
+preview the changes without writing anything.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigMigrate,
+}
 
-	// Check if config file exists
-	exists := true
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		exists = false
+var configExplainCmd = &cobra.Command{
+	Use:   "explain <package>",
+	Short: "Show how the settings of a package are resolved",
+	Long: `Show the effective settings of a package (module path or package name) and whether
+each came from its package entry, the first matching rule or the defaults.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigExplain,
+}
+
+func init() {
+	configCmd.Flags().StringVarP(&configOutput, "output", "o", "table", "Output format: table, json, yaml, csv or markdown")
+	configMigrateCmd.Flags().BoolVar(&configDryRun, "dry-run", false, "Show the changes without writing them")
+
+	for _, cmd := range []*cobra.Command{configSetCmd, configUnsetCmd, configEditCmd, configMigrateCmd} {
+		cmd.Flags().BoolVar(&configRepo, "repo", false, "Write the repository's .tag-manager.yaml instead of the global file")
 	}
 
-	// Display config file location
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configMigrateCmd)
+	configCmd.AddCommand(configExplainCmd)
+}
+
+func runConfig(cmd *cobra.Command, args []string) error {
+	format, err := display.ParseFormat(configOutput)
+	if err != nil {
+		return err
+	}
+	if format != display.FormatTable {
+		return writeConfig(format)
+	}
+
+	// Display config file locations
 	color.Cyan("=== Tag Manager Configuration ===")
-	color.White("Config file: %s", configPath)
-	if exists {
-		color.Green("Status: ✓ Found")
-	} else {
-		color.Yellow("Status: ✗ Not found (will be created on first use)")
+	showConfigFile("Global config file", config.GetConfigPath())
+	if repoPath, _ := config.RepoConfigPath(); repoPath != "" {
+		showConfigFile("Repository config file", repoPath)
 	}
 	color.White("")
 
-	// Load and display configuration
-	cfg, err := config.LoadConfig(configPath)
+	// Load and display the effective configuration
+	cfg, err := loadConfig()
 	if err != nil {
-		if exists {
-			color.Red("Error loading configuration: %v", err)
-			return nil
-		}
-		// If file doesn't exist, show default config
-		color.Yellow("No configuration file found. Here are the defaults:")
-		color.White("")
-
-		// Show default configuration
-		color.Cyan("Default Tag Format: %s", config.GetDefaultConfig().Defaults.TagFormat)
-		color.White("")
-		color.White("Configuration will be created automatically when you:")
-		color.White("  • Run 'tag-manager update' for the first time")
-		color.White("  • Configure a package's tag format")
+		color.Red("Error loading configuration: %v", err)
 		return nil
 	}
 
-	// Display current configuration
-	color.Cyan("Current Configuration:")
+	color.Cyan("Effective Configuration:")
 	color.White("")
 
-	// Show default tag format
-	color.Cyan("Default Tag Format: %s", cfg.Defaults.TagFormat)
+	// Show defaults
+	color.Cyan("Default Tag Format: %s %s", cfg.Defaults.TagFormat, sourceLabel(cfg, "defaults.tag_format"))
+	color.Cyan("Default Remotes: %s %s", strings.Join(cfg.GetRemotes(""), ", "), sourceLabel(cfg, "defaults.remotes"))
 	color.White("")
 
+	// Show rules in the order they are tried
+	if len(cfg.Rules) > 0 {
+		color.Cyan("Rules (%d, first match wins):", len(cfg.Rules))
+		for i := range cfg.Rules {
+			rule := &cfg.Rules[i]
+			color.White("  %s", rule.Describe(i))
+			if rule.TagFormat != "" {
+				color.White("    Tag Format: %s", rule.TagFormat)
+			}
+			if len(rule.Remotes) > 0 {
+				color.White("    Remotes: %s", strings.Join(rule.Remotes, ", "))
+			}
+			if rule.Bump != "" {
+				color.White("    Bump: %s", rule.Bump)
+			}
+			if rule.TagMessage != "" {
+				color.White("    Tag Message: %s", rule.TagMessage)
+			}
+			if rule.VersionScheme != "" {
+				color.White("    Version Scheme: %s", rule.VersionScheme)
+			}
+		}
+		color.White("")
+	}
+
+	// Show version groups
+	if len(cfg.Groups) > 0 {
+		color.Cyan("Version Groups (%d):", len(cfg.Groups))
+		for _, group := range cfg.Groups {
+			color.White("  %s: %s", group.Name, strings.Join(group.Modules, ", "))
+		}
+		color.White("")
+	}
+
 	// Show configured packages
 	if len(cfg.Packages) == 0 {
 		color.Yellow("No packages configured yet.")
 		color.White("Packages will be configured automatically when you run 'tag-manager update'.")
+		return nil
+	}
+
+	modulePaths := make([]string, 0, len(cfg.Packages))
+	for modulePath := range cfg.Packages {
+		modulePaths = append(modulePaths, modulePath)
+	}
+	sort.Strings(modulePaths)
+
+	color.Cyan("Configured Packages (%d):", len(cfg.Packages))
+	for _, modulePath := range modulePaths {
+		pkgConfig := cfg.Packages[modulePath]
+		key := "packages." + modulePath
+		color.White("")
+		color.White("  Package: %s %s", modulePath, sourceLabel(cfg, key))
+		color.White("    Tag Format: %s %s", pkgConfig.TagFormat, sourceLabel(cfg, key+".tag_format"))
+		color.White("    Use Default: %t", pkgConfig.UseDefault)
+		if len(pkgConfig.Remotes) > 0 {
+			color.White("    Remotes: %s %s", strings.Join(pkgConfig.Remotes, ", "), sourceLabel(cfg, key+".remotes"))
+		}
+		if pkgConfig.TagMessage != "" {
+			color.White("    Tag Message: %s", pkgConfig.TagMessage)
+		}
+		if pkgConfig.VersionScheme != "" {
+			color.White("    Version Scheme: %s", pkgConfig.VersionScheme)
+		}
+		if pkgConfig.LastUpdated != "" {
+			color.White("    Last Updated: %s", pkgConfig.LastUpdated)
+		}
+	}
+
+	return nil
+}
+
+// writeConfig writes the effective configuration in a machine readable format
+func writeConfig(format display.Format) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	files := []display.ConfigFileRecord{configFileRecord(config.LayerGlobal, config.GetConfigPath())}
+	if repoPath, _ := config.RepoConfigPath(); repoPath != "" {
+		files = append(files, configFileRecord(config.LayerRepo, repoPath))
+	}
+	return display.WriteConfig(os.Stdout, display.NewConfigRecord(cfg, files), format)
+}
+
+// configFileRecord describes a config file of a layer for machine readable output
+func configFileRecord(layer config.Layer, path string) display.ConfigFileRecord {
+	_, err := os.Stat(path)
+	return display.ConfigFileRecord{Layer: string(layer), Path: path, Exists: err == nil}
+}
+
+// showConfigFile displays a config file location and whether it exists
+func showConfigFile(label, path string) {
+	color.White("%s: %s", label, path)
+	if _, err := os.Stat(path); err == nil {
+		color.Green("  Status: ✓ Found")
+	} else {
+		color.Yellow("  Status: ✗ Not found")
+	}
+}
+
+// sourceLabel formats the layer a configuration value came from
+func sourceLabel(cfg *config.Config, key string) string {
+	return "(" + string(cfg.Source(key)) + ")"
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	var targets []string
+	if len(args) > 0 {
+		target, err := resolveConfigTarget(cfg, args[0])
+		if err != nil {
+			return err
+		}
+		targets = []string{target}
+	} else {
+		targets = append(targets, config.DefaultsTarget)
+		for modulePath := range cfg.Packages {
+			targets = append(targets, modulePath)
+		}
+		sort.Strings(targets[1:])
+	}
+
+	for _, target := range targets {
+		keys := config.PackageKeys
+		if target == config.DefaultsTarget {
+			keys = config.DefaultKeys
+		}
+		if len(args) == 2 {
+			keys = []string{args[1]}
+		}
+
+		for _, key := range keys {
+			value, err := cfg.GetValue(target, key)
+			if err != nil {
+				return err
+			}
+			sourceKey := "packages." + target + "." + key
+			if target == config.DefaultsTarget {
+				sourceKey = "defaults." + key
+			}
+			if len(args) == 2 {
+				fmt.Println(value)
+			} else {
+				fmt.Printf("%s.%s = %s %s\n", target, key, value, sourceLabel(cfg, sourceKey))
+			}
+		}
+	}
+
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	// Nothing is asked, so hold the lock from loading to saving
+	unlock, err := config.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		return err
+	}
+
+	target, err := resolveConfigTarget(cfg, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.SetValue(target, args[1], args[2]); err != nil {
+		return err
+	}
+	if err := config.SaveConfig(cfg, path); err != nil {
+		return err
+	}
+
+	color.Green("Set %s.%s = %s in %s", target, args[1], args[2], path)
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	// Nothing is asked, so hold the lock from loading to saving
+	unlock, err := config.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		return err
+	}
+
+	target, err := resolveConfigTarget(cfg, args[0])
+	if err != nil {
+		return err
+	}
+
+	key := ""
+	if len(args) == 2 {
+		key = args[1]
+	} else if target == config.DefaultsTarget {
+		return fmt.Errorf("a key is required to unset a default value")
+	}
+
+	if err := cfg.UnsetValue(target, key); err != nil {
+		return err
+	}
+	if err := config.SaveConfig(cfg, path); err != nil {
+		return err
+	}
+
+	if key == "" {
+		color.Green("Removed %s from %s", target, path)
+	} else {
+		color.Green("Unset %s.%s in %s", target, key, path)
+	}
+	return nil
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	original, err := os.ReadFile(path)
+	existed := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if !existed {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			return fmt.Errorf("failed to create config file: %w", err)
+		}
+	}
+
+	// restore puts back the file as it was before editing
+	restore := func() error {
+		if !existed {
+			return os.Remove(path)
+		}
+		return os.WriteFile(path, original, 0644)
+	}
+
+	prompter := newPrompter(cmd)
+	for {
+		if err := openEditor(path); err != nil {
+			restore()
+			return err
+		}
+
+		issues, err := config.ValidateFile(path)
+		if err != nil {
+			restore()
+			return err
+		}
+		if len(issues) == 0 {
+			color.Green("Configuration saved and valid: %s", path)
+			return nil
+		}
+
+		color.Red("The configuration has %d issue(s):", len(issues))
+		for _, issue := range issues {
+			color.Red("  • %s", issue)
+		}
+		reopen, err := interactive.AskForConfirmation(prompter, "Re-open the editor to fix them?")
+		if err != nil || !reopen {
+			if err := restore(); err != nil {
+				return fmt.Errorf("failed to restore config file: %w", err)
+			}
+			if err != nil {
+				return handleCancel(err)
+			}
+			return fmt.Errorf("changes discarded, %s was left unchanged", path)
+		}
+	}
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	paths := []string{config.GetConfigPath()}
+	if repoPath, exists := config.RepoConfigPath(); exists {
+		paths = append(paths, repoPath)
+	}
+
+	var issues []config.Issue
+	for _, path := range paths {
+		fileIssues, err := config.ValidateFile(path)
+		if err != nil {
+			return err
+		}
+		issues = append(issues, fileIssues...)
+	}
+
+	if cfg, err := loadConfig(); err == nil {
+		collisionIssues, err := tagCollisionIssues(cfg)
+		if err != nil {
+			return err
+		}
+		issues = append(issues, collisionIssues...)
 	} else {
-		color.Cyan("Configured Packages (%d):", len(cfg.Packages))
-		for modulePath, pkgConfig := range cfg.Packages {
-			color.White("")
-			color.White("  Package: %s", modulePath)
-			color.White("    Tag Format: %s", pkgConfig.TagFormat)
-			color.White("    Use Default: %t", pkgConfig.UseDefault)
-			if pkgConfig.LastUpdated != "" {
-				color.White("    Last Updated: %s", pkgConfig.LastUpdated)
+		issues = append(issues, config.Issue{Message: err.Error()})
+	}
+
+	if len(issues) == 0 {
+		color.Green("Configuration is valid (%s)", strings.Join(paths, ", "))
+		return nil
+	}
+
+	color.Red("Found %d issue(s):", len(issues))
+	for _, issue := range issues {
+		color.Red("  • %s", issue)
+	}
+	return fmt.Errorf("configuration is invalid")
+}
+
+// tagCollisionIssues reports discovered packages of one repository whose tag
+// formats produce the same tags
+func tagCollisionIssues(cfg *config.Config) ([]config.Issue, error) {
+	packages, err := discoverPackages(cfg, discovery.GetDefaultSearchPaths())
+	if err != nil {
+		return nil, err
+	}
+	collisions, err := tagCollisions(cfg, packages)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []config.Issue
+	for _, collision := range collisions {
+		issues = append(issues, config.Issue{Message: fmt.Sprintf("tag collision in %s: %s", collision.Repo, collision)})
+	}
+	return issues, nil
+}
+
+// configFilePath returns the config file written by set, unset and edit
+func configFilePath() (string, error) {
+	if !configRepo {
+		return config.GetConfigPath(), nil
+	}
+	path, _ := config.RepoConfigPath()
+	if path == "" {
+		return "", fmt.Errorf("--repo requires running inside a git repository")
+	}
+	return path, nil
+}
+
+// resolveConfigTarget resolves a package name to its module path. Module
+// paths, configured entries and "defaults" are returned unchanged.
+func resolveConfigTarget(cfg *config.Config, target string) (string, error) {
+	if target == config.DefaultsTarget || strings.Contains(target, "/") {
+		return target, nil
+	}
+	if _, exists := cfg.Packages[target]; exists {
+		return target, nil
+	}
+
+	packages, err := discoverPackages(cfg, discovery.GetDefaultSearchPaths())
+	if err != nil {
+		return "", err
+	}
+	pkg, err := discovery.FindPackage(packages, target)
+	if err != nil {
+		return "", err
+	}
+	return pkg.ModulePath, nil
+}
+
+// openEditor opens path in the user's editor and waits for it to exit
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Run through the shell so editors configured with arguments work
+	editorCmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+	return nil
+}
+
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	result, err := config.MigrateFile(path, configDryRun)
+	if err != nil {
+		return err
+	}
+
+	if !result.Migrated() {
+		color.Green("%s is already at schema version %d", path, config.CurrentSchemaVersion)
+		return nil
+	}
+
+	color.Cyan("Migrating %s from schema version %d to %d:", path, result.From, result.To)
+	for _, migration := range result.Applied {
+		color.White("  %d → %d: %s", migration.From, migration.From+1, migration.Description)
+	}
+	color.White("")
+	showLineDiff(string(result.Before), string(result.After))
+
+	if configDryRun {
+		color.Yellow("\nDry run: no changes were written.")
+		return nil
+	}
+
+	color.Green("\nMigrated %s (backup: %s)", path, config.BackupPath(path, result.From))
+	return nil
+}
+
+// showLineDiff prints the lines removed from and added to a text
+func showLineDiff(before, after string) {
+	a := strings.Split(strings.TrimRight(before, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(after, "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
 			}
 		}
 	}
 
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			color.White("  %s", a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			color.Green("+ %s", b[j])
+			j++
+		default:
+			color.Red("- %s", a[i])
+			i++
+		}
+	}
+}
+
+func runConfigExplain(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	packages, err := discoverPackages(cfg, discovery.GetDefaultSearchPaths())
+	if err != nil {
+		return err
+	}
+	modulePath := args[0]
+	if pkg, err := discovery.FindPackage(packages, args[0]); err == nil {
+		modulePath = pkg.ModulePath
+	} else if !strings.Contains(modulePath, "/") {
+		return err
+	}
+
+	explanation := cfg.Explain(modulePath)
+	color.Cyan("Package: %s", explanation.ModulePath)
+	if explanation.Entry {
+		color.White("Package entry: yes %s", sourceLabel(cfg, "packages."+modulePath))
+	} else {
+		color.White("Package entry: none")
+	}
+	if explanation.Rule != "" {
+		color.Green("Matched rule: %s", explanation.Rule)
+	} else if len(cfg.Rules) > 0 {
+		color.White("Matched rule: none of %d", len(cfg.Rules))
+	}
+	color.White("")
+
+	for _, value := range explanation.Values {
+		display := value.Value
+		if display == "" {
+			display = "-"
+		}
+		color.White("%-15s %-45s from %s", value.Key, display, value.Origin)
+	}
 	return nil
 }
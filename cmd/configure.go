@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/interactive"
+	"github.com/spf13/cobra"
+)
+
+var configureCmd = &cobra.Command{
+	Use:   "configure [package]",
+	Short: "Change the settings of a package interactively",
+	Long: `Show the settings of a package and change its tag format, remotes, tag message
+template and hooks. The package can be given by module path or package name, or
+selected from the discovered packages.
+
+Before saving, an example tag and message are shown, along with a warning when existing
+tags of the package don't match a changed tag format.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigure,
+}
+
+func runConfigure(cmd *cobra.Command, args []string) error {
+	configPath := config.GetConfigPath()
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	packages, err := discoverPackages(cfg, discovery.GetDefaultSearchPaths())
+	if err != nil {
+		return err
+	}
+
+	prompter := newPrompter(cmd)
+	var pkg *discovery.Package
+	if len(args) > 0 {
+		pkg, err = discovery.FindPackage(packages, args[0])
+	} else {
+		pkg, err = interactive.SelectPackage(prompter, packages)
+	}
+	if err != nil {
+		return handleCancel(err)
+	}
+
+	if _, err := interactive.ConfigurePackage(prompter, cfg, *pkg); err != nil {
+		return handleCancel(err)
+	}
+
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	color.Green("Configuration saved to %s", configPath)
+	return nil
+}
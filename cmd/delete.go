@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/interactive"
+	"github.com/gambitier/tag-manager/pkg/retract"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deleteLocalOnly bool
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete <tag>",
+	Short: "Delete a release tag locally and on the configured remotes",
+	Long: `Delete a release tag locally and on the remotes configured for its package.
+
+Go module proxies may already have cached the version, so deleting a published tag
+does not make the version unavailable. You will be offered to publish a retract
+directive instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDelete,
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the last release made by tag-manager",
+	Long: `Delete the tag created by the last 'tag-manager update' locally and on the remotes it was pushed to.
+A release of a version group is reverted as a whole, deleting the tags of every member.`,
+	Args: cobra.NoArgs,
+	RunE: runUndo,
+}
+
+func init() {
+	deleteCmd.Flags().BoolVar(&deleteLocalOnly, "local-only", false, "Only delete the local tag")
+	undoCmd.Flags().BoolVar(&deleteLocalOnly, "local-only", false, "Only delete the local tag")
+}
+
+func runDelete(cmd *cobra.Command, args []string) error {
+	tag := args[0]
+
+	configPath := config.GetConfigPath()
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	packages, err := discoverPackages(cfg, discovery.GetDefaultSearchPaths())
+	if err != nil {
+		return err
+	}
+
+	// Find the package the tag belongs to so its remotes are used
+	pkg := findTagPackage(cfg, packages, tag)
+	dir := ""
+	remotes := cfg.GetRemotes("")
+	if pkg != nil {
+		dir = pkg.Path
+		remotes = cfg.GetRemotes(pkg.ModulePath)
+		color.White("Package: %s", pkg.ModulePath)
+	}
+
+	deleted, err := removeTag(newPrompter(cmd), cfg, configPath, dir, tag, remotes, pkg)
+	if err != nil {
+		return handleCancel(err)
+	}
+
+	if deleted && forgetRelease(cfg, tag) {
+		if err := config.SaveConfig(cfg, configPath); err != nil {
+			color.Yellow("Warning: failed to save configuration: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	configPath := config.GetConfigPath()
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	release := cfg.LastRelease
+	if release == nil {
+		color.Yellow("No release made by tag-manager to undo.")
+		return nil
+	}
+
+	if release.Group != "" {
+		return handleCancel(undoGroupRelease(newPrompter(cmd), cfg, configPath, release))
+	}
+
+	color.Cyan("=== Last Release ===")
+	color.White("Package: %s", release.ModulePath)
+	color.White("Tag: %s", release.Tag)
+	color.White("Commit: %s", release.Commit)
+	color.White("Created: %s", release.CreatedAt)
+
+	// The tag may have been moved since it was created
+	if commit, err := gitutils.ResolveCommit(release.Path, release.Tag); err == nil && commit != release.Commit {
+		color.Yellow("Warning: %s now points at %s, not the released commit", release.Tag, commit)
+	}
+
+	var pkg *discovery.Package
+	if packages, err := discoverPackages(cfg, []string{release.Path}); err == nil {
+		pkg, _ = discovery.FindPackage(packages, release.ModulePath)
+	}
+
+	deleted, err := removeTag(newPrompter(cmd), cfg, configPath, release.Path, release.Tag, release.Remotes, pkg)
+	if err != nil || !deleted {
+		return handleCancel(err)
+	}
+
+	cfg.LastRelease = nil
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		color.Yellow("Warning: failed to save configuration: %v", err)
+	}
+
+	return nil
+}
+
+// undoGroupRelease deletes every tag of a group release locally and on the
+// remotes it was pushed to, after a single confirmation
+func undoGroupRelease(prompter interactive.Prompter, cfg *config.Config, configPath string, release *config.ReleaseRecord) error {
+	color.Cyan("=== Last Release ===")
+	color.White("Version group: %s", release.Group)
+	color.White("Created: %s", release.CreatedAt)
+
+	published := make([][]string, len(release.Members))
+	anyPublished := false
+	for i, member := range release.Members {
+		color.White("  %s: %s", member.ModulePath, member.Tag)
+		if commit, err := gitutils.ResolveCommit(member.Path, member.Tag); err == nil && commit != member.Commit {
+			color.Yellow("  Warning: %s now points at %s, not the released commit", member.Tag, commit)
+		}
+		if deleteLocalOnly {
+			continue
+		}
+		for _, remote := range member.Remotes {
+			hasTag, err := gitutils.RemoteHasTag(member.Path, remote, member.Tag)
+			if err != nil {
+				color.Yellow("Warning: failed to check remote %s: %v", remote, err)
+				continue
+			}
+			if hasTag {
+				published[i] = append(published[i], remote)
+				anyPublished = true
+			}
+		}
+	}
+	if anyPublished {
+		color.Yellow("Warning: Go module proxies (e.g. proxy.golang.org) may already have cached these versions.")
+		color.Yellow("Deleting the tags won't make the versions unavailable to users who can already fetch them.")
+	}
+
+	confirmed, err := interactive.AskForConfirmation(prompter, fmt.Sprintf("Delete all %d tags of group %s?", len(release.Members), release.Group))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		color.Yellow("Tag deletion cancelled.")
+		return nil
+	}
+
+	// Tags deleted by an earlier, interrupted undo are skipped
+	for i, member := range release.Members {
+		for _, remote := range published[i] {
+			if err := gitutils.DeleteRemoteTag(member.Path, remote, member.Tag); err != nil {
+				return fmt.Errorf("failed to delete tag %s on %s: %w", member.Tag, remote, err)
+			}
+			color.Green("Deleted %s on %s", member.Tag, remote)
+		}
+		if gitutils.TagExists(member.Path, member.Tag) {
+			if err := gitutils.DeleteTag(member.Path, member.Tag); err != nil {
+				return fmt.Errorf("failed to delete local tag %s: %w", member.Tag, err)
+			}
+			color.Green("Deleted local tag %s", member.Tag)
+		}
+	}
+
+	cfg.LastRelease = nil
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		color.Yellow("Warning: failed to save configuration: %v", err)
+	}
+	return nil
+}
+
+// forgetRelease removes a deleted tag from the last release, reporting
+// whether the last release changed. A group release keeps its other tags.
+func forgetRelease(cfg *config.Config, tag string) bool {
+	release := cfg.LastRelease
+	if release == nil {
+		return false
+	}
+	if release.Group == "" {
+		if release.Tag != tag {
+			return false
+		}
+		cfg.LastRelease = nil
+		return true
+	}
+
+	for i, member := range release.Members {
+		if member.Tag == tag {
+			release.Members = append(release.Members[:i], release.Members[i+1:]...)
+			if len(release.Members) == 0 {
+				cfg.LastRelease = nil
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// findTagPackage returns the package whose configured tag format produced tag
+func findTagPackage(cfg *config.Config, packages []discovery.Package, tag string) *discovery.Package {
+	for i, pkg := range packages {
+		tagFormat := cfg.GetPackageConfig(pkg.ModulePath).TagFormat
+		packageName := tagutils.ExtractPackageNameFromModule(pkg.ModulePath)
+		if _, ok := tagutils.MatchTag(tagFormat, packageName, tag); ok {
+			return &packages[i]
+		}
+	}
+	return nil
+}
+
+// removeTag deletes tag locally and on remotes after confirmation. When the
+// tag has been published it offers to retract the version instead. It
+// returns whether the tag was deleted.
+func removeTag(prompter interactive.Prompter, cfg *config.Config, configPath, dir, tag string, remotes []string, pkg *discovery.Package) (bool, error) {
+	existsLocally := gitutils.TagExists(dir, tag)
+
+	var published []string
+	if !deleteLocalOnly {
+		for _, remote := range remotes {
+			hasTag, err := gitutils.RemoteHasTag(dir, remote, tag)
+			if err != nil {
+				color.Yellow("Warning: failed to check remote %s: %v", remote, err)
+				continue
+			}
+			if hasTag {
+				published = append(published, remote)
+			}
+		}
+	}
+
+	if !existsLocally && len(published) == 0 {
+		return false, fmt.Errorf("tag %s not found locally or on remotes", tag)
+	}
+
+	color.Cyan("\n=== Tag Deletion Summary ===")
+	color.White("Tag: %s", tag)
+	color.White("Delete locally: %t", existsLocally)
+	if len(published) > 0 {
+		color.White("Delete on remotes: %v", published)
+		color.Yellow("Warning: Go module proxies (e.g. proxy.golang.org) may already have cached this version.")
+		color.Yellow("Deleting the tag won't make the version unavailable to users who can already fetch it.")
+
+		if pkg != nil {
+			retracted, err := offerRetract(prompter, cfg, configPath, pkg, tag)
+			if err != nil || retracted {
+				return false, err
+			}
+		}
+	}
+
+	confirmed, err := interactive.AskForConfirmation(prompter, fmt.Sprintf("Delete tag %s?", tag))
+	if err != nil {
+		return false, err
+	}
+	if !confirmed {
+		color.Yellow("Tag deletion cancelled.")
+		return false, nil
+	}
+
+	for _, remote := range published {
+		if err := gitutils.DeleteRemoteTag(dir, remote, tag); err != nil {
+			return false, fmt.Errorf("failed to delete tag on %s: %w", remote, err)
+		}
+		color.Green("Deleted %s on %s", tag, remote)
+	}
+
+	if existsLocally {
+		if err := gitutils.DeleteTag(dir, tag); err != nil {
+			return false, fmt.Errorf("failed to delete local tag: %w", err)
+		}
+		color.Green("Deleted local tag %s", tag)
+	}
+
+	return true, nil
+}
+
+// offerRetract asks whether to retract the tagged version instead of deleting
+// the tag. It returns true if the user chose to retract.
+func offerRetract(prompter interactive.Prompter, cfg *config.Config, configPath string, pkg *discovery.Package, tag string) (bool, error) {
+	confirmed, err := interactive.AskForConfirmation(prompter, "Publish a retract directive instead of deleting the tag?")
+	if err != nil || !confirmed {
+		return false, err
+	}
+
+	tagFormat := cfg.GetPackageConfig(pkg.ModulePath).TagFormat
+	packageName := tagutils.ExtractPackageNameFromModule(pkg.ModulePath)
+	info, ok := tagutils.MatchTag(tagFormat, packageName, tag)
+	if !ok {
+		return true, fmt.Errorf("cannot determine the version of %s: it doesn't match the tag format %s", tag, tagFormat)
+	}
+	layout, err := cfg.GetCalverLayout(pkg.ModulePath)
+	if err != nil {
+		return true, fmt.Errorf("invalid version scheme of %s: %w", pkg.ModulePath, err)
+	}
+	if layout != "" {
+		info.SetLayout(layout)
+	}
+
+	reason, err := interactive.AskForInput(prompter, "Reason for the retraction")
+	if err != nil {
+		return true, err
+	}
+
+	interval := retract.Interval{Low: info.Version, High: info.Version}
+	if err := retractVersions(prompter, cfg, configPath, pkg, interval, reason); err != nil {
+		if errors.Is(err, interactive.ErrCancelled) {
+			return true, err
+		}
+		return true, fmt.Errorf("failed to retract %s: %w", info.Version, err)
+	}
+	return true, nil
+}
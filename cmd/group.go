@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/apicompat"
+	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/groups"
+	"github.com/gambitier/tag-manager/pkg/hooks"
+	"github.com/gambitier/tag-manager/pkg/interactive"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+)
+
+// groupRelease is the tag a member of a version group is released under
+type groupRelease struct {
+	pkg     *discovery.Package
+	current string
+	version tagutils.TagInfo
+	tag     string
+	commit  string
+	remotes []string
+	release hooks.Release
+}
+
+// releaseGroup runs the tag flow for every member of a version group: the
+// next version is computed from the highest release of the group, and all
+// members are tagged with it after a single confirmation
+func releaseGroup(prompter interactive.Prompter, cfg *config.Config, configPath string, group *groups.Group, selected *discovery.Package, versionType string) error {
+	if releaseRef != "" || pickCommit {
+		return fmt.Errorf("%s is released with version group %s; --ref and --pick-commit only release a single package", selected.ModulePath, group.Name)
+	}
+	color.Cyan("\n%s is released together with version group %s (%d packages)", selected.ModulePath, group.Name, len(group.Members))
+
+	// Every member needs a tag format before its releases can be found
+	packages := make([]discovery.Package, len(group.Members))
+	for i, member := range group.Members {
+		if _, err := interactive.SetupPackageConfig(prompter, cfg, member.Package); err != nil {
+			return fmt.Errorf("failed to setup configuration of %s: %w", member.Package.ModulePath, err)
+		}
+		packages[i] = member.Package
+	}
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		color.Yellow("Warning: failed to save configuration: %v", err)
+	}
+	group, err := groups.Find(cfg, packages, selected.ModulePath)
+	if err != nil {
+		return err
+	}
+	if group == nil {
+		return fmt.Errorf("%s is no longer in a version group", selected.ModulePath)
+	}
+
+	// Members share one version, so they must number it the same way
+	layout, err := cfg.GetCalverLayout(selected.ModulePath)
+	if err != nil {
+		return fmt.Errorf("invalid version scheme of %s: %w", selected.ModulePath, err)
+	}
+	for _, member := range group.Members {
+		if memberLayout, _ := cfg.GetCalverLayout(member.Package.ModulePath); memberLayout != layout {
+			return fmt.Errorf("members of version group %s use different version schemes: %s and %s", group.Name, cfg.GetVersionScheme(selected.ModulePath), cfg.GetVersionScheme(member.Package.ModulePath))
+		}
+	}
+
+	current := group.Latest()
+	currentVersion := current
+	if current == nil {
+		current = &tagutils.TagInfo{Version: "v0.0.0"}
+		if layout != "" {
+			current.SetLayout(layout)
+		}
+	}
+	if currentVersion != nil {
+		color.Cyan("Highest release of the group: %s", currentVersion.Version)
+	}
+
+	// Compare the exported API of every member against its latest release
+	var required string
+	var strongest *apicompat.Report
+	if apiCheck != "off" && layout == "" {
+		for _, member := range group.Members {
+			if member.Tag == "" {
+				continue
+			}
+			report := checkAPI(&member.Package, member.Tag, current.Major)
+			if report == nil {
+				continue
+			}
+			if bump := report.RequiredBump(current.Major); strongest == nil || !apicompat.BumpSatisfies(required, bump) {
+				required, strongest = bump, report
+			}
+		}
+	}
+
+	validate := func(target *tagutils.TagInfo) error {
+		for _, member := range group.Members {
+			format := cfg.GetPackageConfig(member.Package.ModulePath).TagFormat
+			if err := validateTargetVersion(target, currentVersion, &member.Package, format); err != nil {
+				return fmt.Errorf("%s: %w", member.Package.ModulePath, err)
+			}
+		}
+		return nil
+	}
+
+	// Continue the release cycle in progress on the channel, or use the
+	// calendar, the explicit version, the bump policy, or let user select
+	// version type
+	var newVersion *tagutils.TagInfo
+	versions := group.Versions()
+	if releaseChannel != "" {
+		if layout != "" {
+			return fmt.Errorf("--channel can't be used with the calendar versions of version group %s", group.Name)
+		}
+		if newVersion, err = continueChannel(versions); err != nil {
+			return err
+		}
+	}
+	if newVersion != nil {
+		versionType = releaseChannel
+	} else if layout != "" {
+		if targetVersion != "" {
+			return fmt.Errorf("--version can't be used with the calendar versions of version group %s", group.Name)
+		}
+		versionType = tagutils.SchemeCalver
+	} else if targetVersion != "" {
+		newVersion, err = tagutils.ParseVersion(targetVersion)
+		if err != nil {
+			return err
+		}
+		if err := validate(newVersion); err != nil {
+			return err
+		}
+		versionType = interactive.VersionExplicit
+	}
+	if versionType == "" {
+		versionType = versionTypeFromPolicy(cfg, selected.ModulePath, strongest, current.Major)
+	}
+	if versionType == "" {
+		versionType, err = interactive.SelectVersionType(prompter)
+		if err != nil {
+			return fmt.Errorf("failed to select version type: %w", err)
+		}
+	}
+
+	if versionType == interactive.VersionExplicit {
+		if newVersion == nil {
+			newVersion, err = interactive.AskForVersion(prompter, validate)
+			if err != nil {
+				return err
+			}
+		}
+		warnVersionJump(newVersion, currentVersion)
+		newVersion = startChannel(versions, newVersion)
+	} else if versionType != releaseChannel {
+		newVersion, err = tagutils.CalculateNewVersion(current, versionType)
+		if err != nil {
+			return fmt.Errorf("failed to calculate new version: %w", err)
+		}
+		newVersion = startChannel(versions, newVersion)
+	}
+
+	bump := versionType
+	if versionType == interactive.VersionExplicit || releaseChannel != "" {
+		bump = tagutils.VersionType(current, newVersion)
+	}
+	if strongest != nil && bump != "" && !apicompat.BumpSatisfies(bump, required) {
+		if apiCheck == "block" {
+			return fmt.Errorf("a %s release is too small for the API changes in version group %s; at least %s is required", bump, group.Name, required)
+		}
+		color.Yellow("Warning: a %s release is too small for the API changes in version group %s; at least %s is recommended", bump, group.Name, required)
+	}
+
+	// Resolve the tag of every member
+	releases := make([]groupRelease, len(group.Members))
+	for i := range group.Members {
+		pkg := &group.Members[i].Package
+		info := *newVersion
+		info.PackageName = tagutils.ExtractPackageNameFromModule(pkg.ModulePath)
+		tag := tagutils.FormatTag(cfg.GetPackageConfig(pkg.ModulePath).TagFormat, info)
+		if gitutils.TagExists(pkg.Path, tag) {
+			return fmt.Errorf("tag %s of %s already exists", tag, pkg.ModulePath)
+		}
+		commit, err := gitutils.ResolveCommit(pkg.Path, "HEAD")
+		if err != nil {
+			return fmt.Errorf("failed to resolve HEAD of %s: %w", pkg.ModulePath, err)
+		}
+		releases[i] = groupRelease{
+			pkg:     pkg,
+			current: group.Members[i].Tag,
+			version: info,
+			tag:     tag,
+			commit:  commit,
+			remotes: cfg.GetRemotes(pkg.ModulePath),
+			release: hooks.Release{ModulePath: pkg.ModulePath, Path: pkg.Path, Tag: tag, Version: newVersion.Version},
+		}
+	}
+
+	// Display information
+	color.Green("\n=== Group Release Summary ===")
+	color.White("Version group: %s", group.Name)
+	color.Cyan("New version: %s", newVersion.Version)
+	color.Cyan("Version type: %s", versionType)
+	if releaseChannel != "" {
+		color.Cyan("Channel: %s", releaseChannel)
+	}
+	for _, release := range releases {
+		current := release.current
+		if current == "" {
+			current = "(no tags)"
+		}
+		color.White("  %s: %s → %s", release.pkg.ModulePath, current, release.tag)
+		warnModuleVersion(release.pkg, &release.version)
+	}
+
+	// Run quality gates of every member before asking for confirmation
+	if !skipGates {
+		for _, release := range releases {
+			color.Cyan("\n--- %s ---", release.pkg.ModulePath)
+			if err := runGates(cfg, release.pkg); err != nil {
+				return err
+			}
+		}
+	}
+
+	confirmed, err := interactive.AskForConfirmation(prompter, fmt.Sprintf("Do you want to tag all %d packages?", len(releases)))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		color.Yellow("Tag update cancelled.")
+		return nil
+	}
+
+	for _, release := range releases {
+		if preTag := cfg.GetHooks(release.pkg.ModulePath).PreTag; len(preTag) > 0 {
+			color.Cyan("\n=== Running pre-tag hooks of %s ===", release.pkg.ModulePath)
+			if err := hooks.Run(preTag, release.release, os.Stdout); err != nil {
+				return fmt.Errorf("pre-tag hook of %s failed, no tags created: %w", release.pkg.ModulePath, err)
+			}
+		}
+	}
+
+	// Create every tag before pushing any, removing them again on failure
+	for i, release := range releases {
+		message := tagutils.FormatTagMessage(cfg.GetTagMessage(release.pkg.ModulePath), release.tag, release.pkg.ModulePath, release.version)
+		if err := gitutils.CreateTag(release.pkg.Path, release.tag, release.commit, message); err != nil {
+			for _, created := range releases[:i] {
+				if err := gitutils.DeleteTag(created.pkg.Path, created.tag); err != nil {
+					color.Yellow("Warning: failed to remove tag %s: %v", created.tag, err)
+				}
+			}
+			return fmt.Errorf("failed to create tag %s, no tags created: %w", release.tag, err)
+		}
+	}
+	// Record the tags before pushing so undo can remove all of them
+	recordGroupRelease(cfg, configPath, group.Name, releases)
+	for _, release := range releases {
+		for _, remote := range release.remotes {
+			if err := gitutils.PushTag(release.pkg.Path, remote, release.tag); err != nil {
+				return fmt.Errorf("failed to push tag %s to %s: %w", release.tag, remote, err)
+			}
+		}
+		color.Green("Successfully updated tag to %s for package %s", release.tag, release.pkg.ModulePath)
+	}
+
+	for _, release := range releases {
+		if postTag := cfg.GetHooks(release.pkg.ModulePath).PostTag; len(postTag) > 0 {
+			color.Cyan("\n=== Running post-tag hooks of %s ===", release.pkg.ModulePath)
+			if err := hooks.Run(postTag, release.release, os.Stdout); err != nil {
+				color.Yellow("Warning: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// recordGroupRelease remembers the tags of a group release as one release,
+// so undo reverts the whole group
+func recordGroupRelease(cfg *config.Config, configPath, name string, releases []groupRelease) {
+	record := &config.ReleaseRecord{Group: name, CreatedAt: time.Now().Format(time.RFC3339)}
+	for _, release := range releases {
+		member, err := newReleaseRecord(release.pkg, release.tag, release.remotes, record.CreatedAt)
+		if err != nil {
+			color.Yellow("Warning: failed to record release of %s: %v", release.pkg.ModulePath, err)
+			continue
+		}
+		record.Members = append(record.Members, *member)
+	}
+	saveRelease(cfg, configPath, record)
+}
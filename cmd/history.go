@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/display"
+	"github.com/gambitier/tag-manager/pkg/history"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historySince  string
+	historyLimit  int
+	historyOutput string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <package>",
+	Short: "Show release history of a package",
+	Long: `Show every release of a package whose tag matches its configured tag format.
+The package can be given by module path or package name.
+
+For each release the version, tag date, tagger, target commit, annotation subject and
+number of commits since the previous release are shown. Skipped versions and releases
+tagged after a higher version are flagged.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only show releases since a date (YYYY-MM-DD) or newer than a version (e.g. v1.2.0)")
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 0, "Maximum number of releases to show")
+	historyCmd.Flags().StringVarP(&historyOutput, "output", "o", "table", "Output format: table or json")
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	if historyOutput != "table" && historyOutput != "json" {
+		return fmt.Errorf("invalid output format %q: must be table or json", historyOutput)
+	}
+
+	opts := history.Options{Limit: historyLimit}
+	if historySince != "" {
+		if since, err := time.ParseInLocation("2006-01-02", historySince, time.Local); err == nil {
+			opts.Since = since
+		} else if version, err := tagutils.ParseTag(historySince); err == nil {
+			opts.SinceVersion = version
+		} else {
+			return fmt.Errorf("invalid --since value %q: expected a date (YYYY-MM-DD) or a version", historySince)
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	packages, err := discoverPackages(cfg, discovery.GetDefaultSearchPaths())
+	if err != nil {
+		return err
+	}
+
+	pkg, err := discovery.FindPackage(packages, args[0])
+	if err != nil {
+		return err
+	}
+
+	tagFormat := cfg.GetPackageConfig(pkg.ModulePath).TagFormat
+	packageName := tagutils.ExtractPackageNameFromModule(pkg.ModulePath)
+	releases, err := history.Load(pkg.Path, tagFormat, packageName, opts)
+	if err != nil {
+		return fmt.Errorf("failed to load release history: %w", err)
+	}
+
+	if historyOutput == "json" {
+		return display.WriteReleaseHistoryJSON(os.Stdout, releases)
+	}
+
+	color.Cyan("Release history of %s (%s):", pkg.ModulePath, tagFormat)
+	color.White("")
+	display.ShowReleaseHistory(releases)
+	return nil
+}
@@ -2,50 +2,231 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/config"
 	"github.com/gambitier/tag-manager/pkg/discovery"
 	"github.com/gambitier/tag-manager/pkg/display"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/groups"
+	"github.com/gambitier/tag-manager/pkg/namespace"
 	"github.com/spf13/cobra"
 )
 
 var (
-	verbose bool
+	verbose      bool
+	listOutput   string
+	listTemplate string
+	listFilters  []string
+	listSort     string
+	listColumns  []string
+	listTree     bool
 )
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List discovered Go packages",
-	Long:  `List all discovered Go packages across multiple repositories with their configuration status.`,
-	RunE:  runList,
+	Long: `List all discovered Go packages across multiple repositories with their configuration status.
+
+Use --output for machine readable output (json, yaml, csv or markdown) with stable field
+names, or --template to format each package with a Go text/template, e.g.
+  tag-manager list --template '{{.ModulePath}} {{.LatestTag}}'
+Template fields: .ModulePath, .PackageName, .Path, .GoVersion, .GitHubRepo, .LatestTag,
+.LatestPrerelease and .Group.
+
+Filters are terms separated by commas or spaces that must all match:
+  name=auth*            glob on a field (= or !=)
+  module~^github.com/   regular expression on a field
+  go>=1.21              compare versions, dates (YYYY-MM-DD) or counts (>, >=, <, <=)
+  has-tag, !has-tag     packages with or without a tag
+  changed, !changed     packages with or without commits since their latest tag
+  auth*                 a bare word is a glob on the package name
+
+Fields: name, module, path, go, repo, tag, prerelease, version, group, repo_root, dir, tag_date,
+unreleased. The tag is the latest stable release; prerelease is the latest alpha, beta or rc
+tag newer than it.
+--sort takes a field (or date) and sorts descending with a leading -, e.g. --sort -date.
+
+--tree groups packages by git repository, showing its remote and branch, and then by
+directory, so modules nested inside other modules appear below them.
+
+Packages with a pre-release in progress add a Prerelease column after the latest tag.
+Members of version groups get a Group column, and the table is followed by the groups
+whose members' latest releases have drifted apart, and by packages of one repository whose
+tag formats produce the same tags.`,
+	RunE: runList,
 }
 
 func init() {
 	listCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed information (module path, go version, github repo)")
+	listCmd.Flags().StringVarP(&listOutput, "output", "o", "table", "Output format: table, json, yaml, csv or markdown")
+	listCmd.Flags().StringVar(&listTemplate, "template", "", "Format each package with a Go text/template")
+	listCmd.Flags().StringArrayVarP(&listFilters, "filter", "f", nil, "Only list packages matching a filter expression (repeatable)")
+	listCmd.Flags().StringVar(&listSort, "sort", "", "Sort by a field: name, tag, version, date, repo, ... (prefix with - for descending)")
+	listCmd.Flags().StringSliceVar(&listColumns, "columns", nil, "Comma separated fields to show as columns")
+	listCmd.Flags().BoolVar(&listTree, "tree", false, "Group packages by git repository and directory")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
+	format, err := display.ParseFormat(listOutput)
+	if err != nil {
+		return err
+	}
+	if listTemplate != "" && cmd.Flags().Changed("output") {
+		return fmt.Errorf("--template and --output can't be combined")
+	}
+	if listTree && (listTemplate != "" || format != display.FormatTable || len(listColumns) > 0) {
+		return fmt.Errorf("--tree can't be combined with --output, --template or --columns")
+	}
+
+	filter, err := discovery.ParseFilter(strings.Join(listFilters, ","))
+	if err != nil {
+		return err
+	}
+	var columns []discovery.Field
+	for _, name := range listColumns {
+		column, err := discovery.LookupField(strings.TrimSpace(name))
+		if err != nil {
+			return fmt.Errorf("invalid --columns: %w", err)
+		}
+		columns = append(columns, column)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
 	// Discover packages
 	searchPaths := discovery.GetDefaultSearchPaths()
-	packages, err := discovery.DiscoverPackages(searchPaths)
+	discovered, err := discoverPackages(cfg, searchPaths)
 	if err != nil {
-		return fmt.Errorf("failed to discover packages: %w", err)
+		return err
+	}
+	grouped := slices.ContainsFunc(discovered, func(pkg discovery.Package) bool { return pkg.Group != "" })
+	prereleased := slices.ContainsFunc(discovered, func(pkg discovery.Package) bool { return pkg.LatestPrerelease != "" })
+
+	packages := filter.Apply(discovered)
+	if listSort != "" {
+		if err := discovery.SortPackages(packages, listSort); err != nil {
+			return err
+		}
+	}
+
+	if listTemplate != "" {
+		return display.WritePackagesTemplate(os.Stdout, packages, listTemplate)
+	}
+	if format != display.FormatTable {
+		return display.WritePackages(os.Stdout, packages, format, columns)
 	}
 
 	if len(packages) == 0 {
+		if !filter.Empty() {
+			color.Yellow("No packages match the filter.")
+			return nil
+		}
 		color.Red("No Go packages found in the search paths.")
 		color.Yellow("Searched in: %s", strings.Join(searchPaths, ", "))
 		return nil
 	}
 
-	// Determine display mode
-	mode := display.Compact
-	if verbose {
-		mode = display.Verbose
+	if listTree {
+		color.Cyan("Discovered %d Go packages in %s:", len(packages), strings.Join(searchPaths, ", "))
+		color.White("")
+		display.ShowPackageTree(packages)
+		return showListFooter(cfg, discovered, grouped)
+	}
+
+	// Determine columns from the display mode unless chosen explicitly
+	if len(columns) == 0 {
+		mode := display.Compact
+		if verbose {
+			mode = display.Verbose
+		}
+		columns = mode.Columns()
+		if prereleased {
+			prerelease, _ := discovery.LookupField("prerelease")
+			columns = slices.Insert(columns, slices.IndexFunc(columns, func(field discovery.Field) bool { return field.Name == "tag" })+1, prerelease)
+		}
+		if grouped {
+			group, _ := discovery.LookupField("group")
+			columns = append(columns, group)
+		}
+	}
+	for _, column := range columns {
+		if column.Details {
+			discovery.LoadDetails(packages)
+			break
+		}
 	}
 
 	// Show package list with header
-	display.ShowPackageListWithHeader(packages, mode, searchPaths)
+	display.ShowPackageListWithHeader(packages, columns, searchPaths)
+	return showListFooter(cfg, discovered, grouped)
+}
+
+// showListFooter shows the version groups and tag collisions of the
+// discovered packages below the package list
+func showListFooter(cfg *config.Config, packages []discovery.Package, grouped bool) error {
+	if err := showVersionGroups(cfg, packages, grouped); err != nil {
+		return err
+	}
+
+	collisions, err := tagCollisions(cfg, packages)
+	if err != nil {
+		color.Yellow("Warning: failed to check tag collisions: %v", err)
+		return nil
+	}
+	if len(collisions) > 0 {
+		color.Red("\nTag collisions:")
+		for _, collision := range collisions {
+			color.Red("  • %s", collision)
+		}
+		color.Yellow("Give these packages distinct tag formats with 'tag-manager configure <package>'.")
+	}
+	return nil
+}
+
+// tagCollisions returns the tag collisions in the repositories of packages
+func tagCollisions(cfg *config.Config, packages []discovery.Package) ([]namespace.Collision, error) {
+	var collisions []namespace.Collision
+	var roots []string
+	for _, pkg := range packages {
+		inKnownRoot := slices.ContainsFunc(roots, func(root string) bool {
+			return pkg.Path == root || strings.HasPrefix(pkg.Path, root+string(filepath.Separator))
+		})
+		if inKnownRoot {
+			continue
+		}
+		root, err := gitutils.RepoRoot(pkg.Path)
+		if err != nil || slices.Contains(roots, root) {
+			continue
+		}
+		roots = append(roots, root)
+
+		found, err := namespace.Find(cfg, root)
+		if err != nil {
+			return nil, err
+		}
+		collisions = append(collisions, found...)
+	}
+	return collisions, nil
+}
+
+// showVersionGroups shows the version groups of the discovered packages and
+// whether their members drifted apart
+func showVersionGroups(cfg *config.Config, packages []discovery.Package, grouped bool) error {
+	if !grouped {
+		return nil
+	}
+	versionGroups, err := groups.Resolve(cfg, packages)
+	if err != nil {
+		return err
+	}
+	display.ShowVersionGroups(versionGroups)
 	return nil
 }
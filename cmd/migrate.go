@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/interactive"
+	"github.com/gambitier/tag-manager/pkg/migrate"
+	"github.com/gambitier/tag-manager/pkg/namespace"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateFromFormat string
+	migrateToFormat   string
+	migrateDeleteOld  bool
+	migrateLocalOnly  bool
+	migrateDryRun     bool
+)
+
+var migrateTagsCmd = &cobra.Command{
+	Use:   "migrate-tags <package>",
+	Short: "Move the tags of a package to a new tag format",
+	Long: `Create a tag in the new format for every tag of a package in the old format, pointing
+at the same commit, e.g. utils/v1.2.3 for utils-v1.2.3. The package is given by module
+path or package name; --from-format defaults to its configured tag format.
+
+The new tags keep the annotation and tagger date of the old ones; their message also
+records the old tag, its tagger and date. They are pushed to the package's remotes
+unless --local-only is set. With --delete-old the old tags are deleted locally and on
+the remotes afterwards.
+
+The full mapping is shown for confirmation first, and the package's tag format is set
+to the new format at the end. Running the migration again skips tags already migrated.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMigrateTags,
+}
+
+func init() {
+	migrateTagsCmd.Flags().StringVar(&migrateFromFormat, "from-format", "", "Tag format of the existing tags (default: the configured format)")
+	migrateTagsCmd.Flags().StringVar(&migrateToFormat, "to-format", "", "Tag format to migrate the tags to (required)")
+	migrateTagsCmd.Flags().BoolVar(&migrateDeleteOld, "delete-old", false, "Delete the old tags after migrating them")
+	migrateTagsCmd.Flags().BoolVar(&migrateLocalOnly, "local-only", false, "Only create and delete local tags")
+	migrateTagsCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Show the mapping without changing anything")
+	migrateTagsCmd.MarkFlagRequired("to-format")
+}
+
+func runMigrateTags(cmd *cobra.Command, args []string) error {
+	configPath := config.GetConfigPath()
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	packages, err := discoverPackages(cfg, discovery.GetDefaultSearchPaths())
+	if err != nil {
+		return err
+	}
+	pkg, err := discovery.FindPackage(packages, args[0])
+	if err != nil {
+		return err
+	}
+
+	fromFormat := migrateFromFormat
+	if fromFormat == "" {
+		fromFormat = cfg.GetPackageConfig(pkg.ModulePath).TagFormat
+	}
+	for _, format := range []string{fromFormat, migrateToFormat} {
+		if err := tagutils.ValidateTagFormat(format); err != nil {
+			return fmt.Errorf("invalid tag format %s: %w", format, err)
+		}
+	}
+	if fromFormat == migrateToFormat {
+		return fmt.Errorf("--from-format and --to-format are both %s", fromFormat)
+	}
+	if err := checkMigrationCollisions(cfg, pkg, migrateToFormat); err != nil {
+		return err
+	}
+
+	layout, err := cfg.GetCalverLayout(pkg.ModulePath)
+	if err != nil {
+		return fmt.Errorf("invalid version scheme: %w", err)
+	}
+	packageName := tagutils.ExtractPackageNameFromModule(pkg.ModulePath)
+	mappings, skipped, err := migrate.Plan(pkg.Path, fromFormat, migrateToFormat, packageName, layout)
+	if err != nil {
+		return err
+	}
+	if len(mappings) == 0 {
+		color.Yellow("No tags of %s match %s.", pkg.ModulePath, fromFormat)
+		return nil
+	}
+
+	var remotes []string
+	if !migrateLocalOnly {
+		remotes = cfg.GetRemotes(pkg.ModulePath)
+	}
+	showMigration(pkg, fromFormat, mappings, skipped, remotes)
+	if migrateDryRun {
+		return nil
+	}
+
+	confirmed, err := interactive.AskForConfirmation(newPrompter(cmd), fmt.Sprintf("Migrate %d tag(s)?", len(mappings)))
+	if err != nil {
+		return handleCancel(err)
+	}
+	if !confirmed {
+		color.Yellow("Tag migration cancelled.")
+		return nil
+	}
+
+	if err := createMigratedTags(pkg, mappings, remotes); err != nil {
+		return err
+	}
+	// The last release must keep naming a tag undo can delete
+	save := false
+	if migrateDeleteOld {
+		deleteMigratedTags(pkg, mappings, remotes)
+		save = renameReleaseTags(cfg.LastRelease, mappings)
+	}
+
+	formatChanged := cfg.GetPackageConfig(pkg.ModulePath).TagFormat != migrateToFormat
+	if formatChanged {
+		if err := cfg.SetValue(pkg.ModulePath, "tag_format", migrateToFormat); err != nil {
+			return err
+		}
+		save = true
+	}
+	if save {
+		if err := config.SaveConfig(cfg, configPath); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+	}
+	if formatChanged {
+		color.Green("Tag format of %s set to %s", pkg.ModulePath, migrateToFormat)
+	}
+	return nil
+}
+
+// renameReleaseTags points the tags of release, or of the members of a group
+// release, that were migrated at their new tags. It reports whether any was
+// renamed.
+func renameReleaseTags(release *config.ReleaseRecord, mappings []migrate.Mapping) bool {
+	if release == nil {
+		return false
+	}
+
+	renamed := false
+	rename := func(record *config.ReleaseRecord) {
+		for _, mapping := range mappings {
+			if record.Tag == mapping.Old.Name {
+				record.Tag = mapping.New
+				renamed = true
+				return
+			}
+		}
+	}
+	rename(release)
+	for i := range release.Members {
+		rename(&release.Members[i])
+	}
+	return renamed
+}
+
+// checkMigrationCollisions refuses a target format producing the tags of
+// another package of the repository
+func checkMigrationCollisions(cfg *config.Config, pkg *discovery.Package, format string) error {
+	root, err := gitutils.RepoRoot(pkg.Path)
+	if err != nil {
+		return fmt.Errorf("%s is not in a git repository: %w", pkg.Path, err)
+	}
+	collisions, err := namespace.Check(cfg, root, pkg.ModulePath, format)
+	if err != nil {
+		return err
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, len(collisions))
+	for i, collision := range collisions {
+		descriptions[i] = collision.String()
+	}
+	return fmt.Errorf("tag format %s collides with other packages: %s", format, strings.Join(descriptions, "; "))
+}
+
+// showMigration previews the mapping from old to new tags
+func showMigration(pkg *discovery.Package, fromFormat string, mappings []migrate.Mapping, skipped []string, remotes []string) {
+	color.Cyan("=== Tag Migration ===")
+	color.White("Package: %s", pkg.ModulePath)
+	color.White("From format: %s", fromFormat)
+	color.White("To format: %s", migrateToFormat)
+	for _, mapping := range mappings {
+		if mapping.Exists {
+			color.White("  %s → %s (already exists)", mapping.Old.Name, mapping.New)
+		} else {
+			color.White("  %s → %s", mapping.Old.Name, mapping.New)
+		}
+	}
+	for _, tag := range skipped {
+		color.Yellow("  %s skipped: not produced by %s", tag, fromFormat)
+	}
+
+	if len(remotes) > 0 {
+		color.White("Push to remotes: %v", remotes)
+	} else {
+		color.White("Push to remotes: none")
+	}
+	if migrateDeleteOld {
+		color.White("Delete old tags: locally and on remotes %v", remotes)
+		if len(remotes) > 0 {
+			color.Yellow("Warning: Go module proxies may already have cached the old tags; deleting them won't make those versions unavailable.")
+		}
+	}
+}
+
+// createMigratedTags creates the new tags of mappings and pushes them
+func createMigratedTags(pkg *discovery.Package, mappings []migrate.Mapping, remotes []string) error {
+	for _, mapping := range mappings {
+		if !mapping.Exists {
+			message, err := migrate.Message(pkg.Path, mapping)
+			if err != nil {
+				return err
+			}
+			if err := gitutils.CreateTagAt(pkg.Path, mapping.New, mapping.Old.Commit, message, mapping.Old.Date); err != nil {
+				return fmt.Errorf("failed to create tag %s: %w", mapping.New, err)
+			}
+			color.Green("Created %s", mapping.New)
+		}
+		for _, remote := range remotes {
+			if err := gitutils.PushTag(pkg.Path, remote, mapping.New); err != nil {
+				return fmt.Errorf("failed to push tag %s to %s: %w", mapping.New, remote, err)
+			}
+		}
+	}
+	if len(remotes) > 0 {
+		color.Green("Pushed %d tag(s) to %v", len(mappings), remotes)
+	}
+	return nil
+}
+
+// deleteMigratedTags deletes the old tags of mappings on remotes and locally
+func deleteMigratedTags(pkg *discovery.Package, mappings []migrate.Mapping, remotes []string) {
+	for _, mapping := range mappings {
+		tag := mapping.Old.Name
+		if tag == mapping.New {
+			continue
+		}
+		for _, remote := range remotes {
+			hasTag, err := gitutils.RemoteHasTag(pkg.Path, remote, tag)
+			if err != nil {
+				color.Yellow("Warning: failed to check remote %s: %v", remote, err)
+				continue
+			}
+			if !hasTag {
+				continue
+			}
+			if err := gitutils.DeleteRemoteTag(pkg.Path, remote, tag); err != nil {
+				color.Yellow("Warning: failed to delete %s from %s: %v", tag, remote, err)
+			}
+		}
+		if err := gitutils.DeleteTag(pkg.Path, tag); err != nil {
+			color.Yellow("Warning: failed to delete local tag %s: %v", tag, err)
+			continue
+		}
+		color.Green("Deleted %s", tag)
+	}
+}
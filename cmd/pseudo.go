@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/pseudo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pseudoRef string
+)
+
+var pseudoCmd = &cobra.Command{
+	Use:   "pseudo <package>",
+	Short: "Show the Go pseudo-version of an untagged commit",
+	Long: `Show the version the Go toolchain resolves a commit of a package to, given by module
+path or package name, together with a go get command for it.
+
+An untagged commit gets a pseudo-version built on the highest release reachable from it
+whose tag has the module's tag prefix (its directory in the repository) and matches its
+major version, using the commit's UTC time and 12 character hash. A commit tagged with a
+release resolves to that release instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPseudo,
+}
+
+func init() {
+	pseudoCmd.Flags().StringVar(&pseudoRef, "ref", "HEAD", "Commit, branch or tag to compute the version of")
+}
+
+func runPseudo(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	packages, err := discoverPackages(cfg, discovery.GetDefaultSearchPaths())
+	if err != nil {
+		return err
+	}
+	pkg, err := discovery.FindPackage(packages, args[0])
+	if err != nil {
+		return err
+	}
+	selected := []discovery.Package{*pkg}
+	discovery.LoadDetails(selected)
+	if selected[0].Details.RepoRoot == "" {
+		return fmt.Errorf("%s is not in a git repository", pkg.ModulePath)
+	}
+
+	version, err := pseudo.Compute(pkg.Path, selected[0].Details.Dir, pkg.ModulePath, pseudoRef)
+	if err != nil {
+		return err
+	}
+
+	color.White("Module: %s", pkg.ModulePath)
+	color.White("Commit: %s (%s)", version.Commit[:min(12, len(version.Commit))], version.Time.Format("2006-01-02 15:04:05 MST"))
+	switch {
+	case version.Tag != "":
+		color.Yellow("The commit is tagged %s, so Go resolves it to that release", version.Tag)
+	case version.BaseTag != "":
+		color.White("Based on: %s", version.BaseTag)
+	default:
+		color.White("Based on: no reachable release")
+	}
+	color.Green("Version: %s", version.Version)
+	color.White("\ngo get %s@%s", pkg.ModulePath, version.Version)
+	return nil
+}
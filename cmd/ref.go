@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/interactive"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+	"golang.org/x/mod/modfile"
+)
+
+// recentCommits is the number of commits offered by --pick-commit
+const recentCommits = 20
+
+// releaseCommit returns the commit a release tags: HEAD, --ref, or a commit
+// picked from the recent commits touching the package. A given or picked
+// commit must contain the package's go.mod. When a release branch is
+// configured, or a commit other than HEAD is released, the commit must be
+// reachable from the release branch.
+func releaseCommit(prompter interactive.Prompter, cfg *config.Config, pkg *discovery.Package) (*gitutils.Commit, error) {
+	explicit := releaseRef != "" || pickCommit
+	branch := cfg.GetReleaseBranch(pkg.ModulePath)
+	if branch == "" && explicit {
+		// Fall back to the default branch of the remote tags are pushed to
+		remote := cfg.GetRemotes(pkg.ModulePath)[0]
+		var err error
+		if branch, err = gitutils.DefaultBranch(pkg.Path, remote); err != nil {
+			return nil, fmt.Errorf("can't tell the release branch of %s: %s has no default branch; set one with 'tag-manager config set %s release_branch <branch>'",
+				pkg.ModulePath, remote, pkg.ModulePath)
+		}
+	}
+
+	ref := "HEAD"
+	if releaseRef != "" {
+		ref = releaseRef
+	}
+	if pickCommit {
+		from := branch
+		if from == "" {
+			from = ref
+		}
+		commits, err := gitutils.Log(pkg.Path, from, recentCommits, ".")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits: %w", err)
+		}
+		picked, err := interactive.SelectCommit(prompter, commits)
+		if err != nil {
+			return nil, err
+		}
+		ref = picked.Hash
+	}
+
+	hash, err := gitutils.ResolveCommit(pkg.Path, ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ref %q: no such commit in %s", ref, pkg.Path)
+	}
+	commits, err := gitutils.Log(pkg.Path, hash, 1)
+	if err != nil || len(commits) == 0 {
+		return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+	commit := &commits[0]
+
+	if explicit {
+		data, err := gitutils.ShowFile(pkg.Path, commit.Hash, "go.mod")
+		if err != nil {
+			return nil, fmt.Errorf("commit %s doesn't contain %s", commit.ShortHash, filepath.Join(pkg.Path, "go.mod"))
+		}
+		if modulePath := modfile.ModulePath([]byte(data)); modulePath != pkg.ModulePath {
+			return nil, fmt.Errorf("go.mod at commit %s declares module %q, expected %s", commit.ShortHash, modulePath, pkg.ModulePath)
+		}
+	}
+
+	if branch != "" {
+		reachable, err := gitutils.IsAncestor(pkg.Path, commit.Hash, branch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check release branch %s: %w", branch, err)
+		}
+		if !reachable {
+			return nil, fmt.Errorf("commit %s is not reachable from the release branch %s", commit.ShortHash, branch)
+		}
+	}
+
+	return commit, nil
+}
+
+// confirmReleasePosition warns when an explicitly chosen commit already
+// carries a release of the package or is older than its latest release,
+// tagged latestTag, and asks whether to release it anyway
+func confirmReleasePosition(prompter interactive.Prompter, pkg *discovery.Package, commit *gitutils.Commit, format, latestTag string) error {
+	packageName := tagutils.ExtractPackageNameFromModule(pkg.ModulePath)
+	tags, err := gitutils.TagsAt(pkg.Path, commit.Hash, tagutils.FormatGlob(format, packageName))
+	if err != nil {
+		return fmt.Errorf("failed to list tags of %s: %w", commit.ShortHash, err)
+	}
+
+	var warnings []string
+	for _, tag := range tags {
+		if _, ok := tagutils.MatchTag(format, packageName, tag); ok {
+			warnings = append(warnings, fmt.Sprintf("commit %s is already released as %s", commit.ShortHash, tag))
+		}
+	}
+	if len(warnings) == 0 && latestTag != "" {
+		latestCommit, err := gitutils.ResolveCommit(pkg.Path, latestTag)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", latestTag, err)
+		}
+		older, err := gitutils.IsAncestor(pkg.Path, commit.Hash, latestCommit)
+		if err != nil {
+			return err
+		}
+		if older {
+			warnings = append(warnings, fmt.Sprintf("commit %s is older than the latest release %s", commit.ShortHash, latestTag))
+		}
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	for _, warning := range warnings {
+		color.Yellow("Warning: %s", warning)
+	}
+	confirmed, err := interactive.AskForConfirmation(prompter, "Release this commit anyway?")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return interactive.ErrCancelled
+	}
+	return nil
+}
+
+// checkoutCommit checks out commit in a temporary worktree, so gates and the
+// API check see the package as released. It returns the package with its
+// path in the worktree and a function removing the worktree.
+func checkoutCommit(pkg *discovery.Package, commit string) (*discovery.Package, func(), error) {
+	prefix, err := gitutils.Run(pkg.Path, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find package directory: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "tag-manager-release-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	worktree := filepath.Join(tmpDir, "worktree")
+	if err := gitutils.AddWorktree(pkg.Path, worktree, commit); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, nil, fmt.Errorf("failed to check out %s: %w", commit, err)
+	}
+
+	cleanup := func() {
+		if err := gitutils.RemoveWorktree(pkg.Path, worktree); err != nil {
+			color.Yellow("Warning: failed to remove worktree %s: %v", worktree, err)
+		}
+		os.RemoveAll(tmpDir)
+	}
+
+	checkedOut := *pkg
+	checkedOut.Path = filepath.Join(worktree, filepath.FromSlash(prefix))
+	return &checkedOut, cleanup, nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/interactive"
+	"github.com/gambitier/tag-manager/pkg/retract"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	retractReason string
+)
+
+var retractCmd = &cobra.Command{
+	Use:   "retract <package> <version|range>",
+	Short: "Retract a published version of a package",
+	Long: `Retract a version or range of versions of a package, given by module path or package name.
+
+A retract directive with the reason as its comment is added to the package's go.mod,
+the change is committed, and the next patch version containing the retraction is
+released through the normal tag flow.
+
+Ranges can be written as [v1.2.0, v1.2.3] or v1.2.0..v1.2.3. Every retracted version
+boundary must exist as a tag.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRetract,
+}
+
+func init() {
+	retractCmd.Flags().StringVar(&retractReason, "reason", "", "Reason for the retraction, written as a comment in go.mod (required)")
+	retractCmd.MarkFlagRequired("reason")
+	addReleaseFlags(retractCmd)
+}
+
+func runRetract(cmd *cobra.Command, args []string) error {
+	if err := validateReleaseFlags(); err != nil {
+		return err
+	}
+
+	interval, err := retract.ParseInterval(args[1])
+	if err != nil {
+		return err
+	}
+
+	configPath := config.GetConfigPath()
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	packages, err := discoverPackages(cfg, discovery.GetDefaultSearchPaths())
+	if err != nil {
+		return err
+	}
+
+	pkg, err := discovery.FindPackage(packages, args[0])
+	if err != nil {
+		return err
+	}
+
+	return handleCancel(retractVersions(newPrompter(cmd), cfg, configPath, pkg, interval, retractReason))
+}
+
+// retractVersions adds a retract directive for interval to the package's
+// go.mod, commits it and releases the next patch version
+func retractVersions(prompter interactive.Prompter, cfg *config.Config, configPath string, pkg *discovery.Package, interval retract.Interval, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("a reason for the retraction is required")
+	}
+
+	// Only versions that were actually released can be retracted
+	tagFormat := cfg.GetPackageConfig(pkg.ModulePath).TagFormat
+	layout, err := cfg.GetCalverLayout(pkg.ModulePath)
+	if err != nil {
+		return fmt.Errorf("invalid version scheme of %s: %w", pkg.ModulePath, err)
+	}
+	for _, version := range []string{interval.Low, interval.High} {
+		tag, err := versionTag(tagFormat, layout, pkg.ModulePath, version)
+		if err != nil {
+			return err
+		}
+		if !gitutils.TagExists(pkg.Path, tag) {
+			return fmt.Errorf("cannot retract %s: tag %s does not exist", version, tag)
+		}
+	}
+
+	goModPath := filepath.Join(pkg.Path, "go.mod")
+	if status, err := gitutils.Run(pkg.Path, "status", "--porcelain", "--", "go.mod"); err != nil {
+		return fmt.Errorf("failed to check go.mod status: %w", err)
+	} else if status != "" {
+		return fmt.Errorf("%s has uncommitted changes; commit or stash them first", goModPath)
+	}
+
+	color.Cyan("\n=== Retraction Summary ===")
+	color.White("Package: %s", pkg.ModulePath)
+	color.White("Retract: %s", interval)
+	color.White("Reason: %s", reason)
+	color.White("go.mod: %s", goModPath)
+
+	confirmed, err := interactive.AskForConfirmation(prompter, "Add the retract directive and commit it?")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		color.Yellow("Retraction cancelled.")
+		return nil
+	}
+
+	if err := retract.AddToGoMod(goModPath, interval, reason); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Retract %s of %s\n\n%s", interval, pkg.ModulePath, reason)
+	if _, err := gitutils.Run(pkg.Path, "commit", "-m", message, "--", "go.mod"); err != nil {
+		return fmt.Errorf("failed to commit go.mod: %w", err)
+	}
+	color.Green("Committed retraction of %s", interval)
+
+	// The retraction only takes effect once a newer version containing it is published
+	color.Cyan("\nReleasing the next patch version with the retraction...")
+	return releasePackage(prompter, cfg, configPath, pkg, "patch")
+}
+
+// versionTag returns the tag a version of a package is released under. An
+// empty layout is semver.
+func versionTag(tagFormat, layout, modulePath, version string) (string, error) {
+	info, err := tagutils.ParseTag(version)
+	if err != nil {
+		return "", fmt.Errorf("unsupported version %s: %w", version, err)
+	}
+	info.PackageName = tagutils.ExtractPackageNameFromModule(modulePath)
+	if layout != "" {
+		info.SetLayout(layout)
+	}
+	return tagutils.FormatTag(tagFormat, *info), nil
+}
@@ -1,9 +1,22 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/interactive"
 	"github.com/spf13/cobra"
 )
 
+var (
+	tagFormatOverride string
+	remoteOverrides   []string
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "tag-manager",
 	Short: "A generic tool for managing tags across Go repositories",
@@ -24,8 +37,67 @@ func Execute() error {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&tagFormatOverride, "tag-format", "", "Override the tag format of every package for this run")
+	rootCmd.PersistentFlags().StringSliceVar(&remoteOverrides, "remote", nil, "Override the remotes tags are pushed to for this run (repeatable)")
+
 	// Add subcommands
 	rootCmd.AddCommand(updateCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(configureCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(retractCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(pseudoCmd)
+	rootCmd.AddCommand(migrateTagsCmd)
+}
+
+// loadConfig loads the layered configuration with command line overrides applied
+func loadConfig() (*config.Config, error) {
+	return config.Load(config.Overrides{
+		TagFormat: tagFormatOverride,
+		Remotes:   remoteOverrides,
+	})
+}
+
+// newPrompter returns a prompter reading answers from the command's input
+func newPrompter(cmd *cobra.Command) interactive.Prompter {
+	out := cmd.OutOrStdout()
+	if out == os.Stdout {
+		// Keep colors working on Windows consoles
+		out = color.Output
+	}
+	return interactive.NewPrompter(cmd.InOrStdin(), out)
+}
+
+// handleCancel turns a prompt cancelled by the end of input (Ctrl-D) into a
+// notice instead of an error
+func handleCancel(err error) error {
+	if errors.Is(err, interactive.ErrCancelled) {
+		color.Yellow("Cancelled.")
+		return nil
+	}
+	return err
+}
+
+// discoverPackages discovers packages in searchPaths, records their
+// locations in cfg so rules matching on directory or repository apply, and
+// sets the latest tags and version group of each package
+func discoverPackages(cfg *config.Config, searchPaths []string) ([]discovery.Package, error) {
+	packages, err := discovery.DiscoverPackages(searchPaths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover packages: %w", err)
+	}
+	for i, pkg := range packages {
+		cfg.SetPackageLocation(pkg.ModulePath, pkg.Path, pkg.GitHubRepo)
+		// An invalid scheme is reported when the package is released
+		layout, _ := cfg.GetCalverLayout(pkg.ModulePath)
+		packages[i].LoadTags(cfg.GetPackageConfig(pkg.ModulePath).TagFormat, layout)
+		if group := cfg.GroupOf(pkg.ModulePath); group != nil {
+			packages[i].Group = group.Name
+		}
+	}
+	return packages, nil
 }
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/apicompat"
+	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/history"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+)
+
+var (
+	snapshotName       string
+	snapshotTimestamp  string
+	snapshotHashLength int
+	snapshotNoPush     bool
+	snapshotRetention  int
+	snapshotDryRun     bool
+	snapshotFilters    []string
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot [package...]",
+	Short: "Tag snapshot pre-releases of packages with unreleased changes",
+	Long: `Tag the HEAD of every package with commits since its latest release with a snapshot
+pre-release of its next version, such as v1.5.0-nightly.20261017.abc1234.
+
+The next version is the bump required by the exported API changes, at least the package's
+bump policy when it names a version type, or the version of a pre-release in progress.
+The snapshot pre-release is the name, the current UTC time formatted with the Go time
+layout of --timestamp and the abbreviated commit hash.
+
+Snapshot tags of the name older than --retention days are deleted locally and, unless
+--no-push is set, on the package's remotes. Packages are given by module path or package
+name, or selected with --filter; without either, all discovered packages are snapshotted.`,
+	RunE: runSnapshot,
+}
+
+func init() {
+	snapshotCmd.Flags().StringVar(&snapshotName, "name", "nightly", "Name the snapshot pre-release starts with")
+	snapshotCmd.Flags().StringVar(&snapshotTimestamp, "timestamp", "20060102", "Go time layout of the snapshot timestamp (empty to leave it out)")
+	snapshotCmd.Flags().IntVar(&snapshotHashLength, "hash-length", 7, "Length of the commit hash in the snapshot (0 to leave it out)")
+	snapshotCmd.Flags().BoolVar(&snapshotNoPush, "no-push", false, "Only create and prune local tags")
+	snapshotCmd.Flags().IntVar(&snapshotRetention, "retention", 14, "Delete snapshot tags older than this many days (0 to keep all)")
+	snapshotCmd.Flags().BoolVar(&snapshotDryRun, "dry-run", false, "Show the snapshot tags to create and prune without changing anything")
+	snapshotCmd.Flags().StringArrayVarP(&snapshotFilters, "filter", "f", nil, "Only snapshot packages matching a filter expression, as in list (repeatable)")
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) error {
+	if err := tagutils.ValidateSnapshotName(snapshotName); err != nil {
+		return err
+	}
+	if snapshotHashLength < 0 || snapshotRetention < 0 {
+		return fmt.Errorf("--hash-length and --retention can't be negative")
+	}
+	filter, err := discovery.ParseFilter(strings.Join(snapshotFilters, ","))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	packages, err := discoverPackages(cfg, discovery.GetDefaultSearchPaths())
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		var selected []discovery.Package
+		for _, query := range args {
+			pkg, err := discovery.FindPackage(packages, query)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, *pkg)
+		}
+		packages = selected
+	}
+	packages = filter.Apply(packages)
+	if len(packages) == 0 {
+		color.Yellow("No packages to snapshot.")
+		return nil
+	}
+
+	now := time.Now().UTC()
+	var failed []string
+	for i := range packages {
+		pkg := &packages[i]
+		if err := snapshotPackage(cfg, pkg, now); err != nil {
+			color.Red("%s: %v", pkg.ModulePath, err)
+			failed = append(failed, pkg.ModulePath)
+		}
+		if snapshotRetention > 0 {
+			if err := pruneSnapshots(cfg, pkg, now.AddDate(0, 0, -snapshotRetention)); err != nil {
+				color.Red("%s: %v", pkg.ModulePath, err)
+				failed = append(failed, pkg.ModulePath)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("snapshot failed for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// snapshotPackage tags the HEAD of a package with unreleased changes with a
+// snapshot of its next version
+func snapshotPackage(cfg *config.Config, pkg *discovery.Package, now time.Time) error {
+	format := cfg.GetPackageConfig(pkg.ModulePath).TagFormat
+	currentTag, err := history.LatestStableTag(pkg.Path, format, tagutils.ExtractPackageNameFromModule(pkg.ModulePath))
+	if err != nil {
+		return err
+	}
+	unreleased, err := history.CommitsSince(pkg.Path, currentTag)
+	if err != nil {
+		return err
+	}
+	if unreleased == 0 {
+		color.White("%s: no unreleased changes", pkg.ModulePath)
+		return nil
+	}
+
+	next, err := nextSnapshotVersion(cfg, pkg, currentTag)
+	if err != nil {
+		return err
+	}
+	commit, err := gitutils.ResolveCommit(pkg.Path, "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	next.SetSnapshot(tagutils.SnapshotSuffix(snapshotName, snapshotTimestamp, now, commit, snapshotHashLength))
+	if next.Layout == "" && !semver.IsValid(next.Version) {
+		return fmt.Errorf("snapshot version %s is not a valid semantic version; check --timestamp and --hash-length", next.Version)
+	}
+
+	tag := tagutils.FormatTag(format, *next)
+	if gitutils.TagExists(pkg.Path, tag) {
+		color.White("%s: snapshot %s already exists", pkg.ModulePath, tag)
+		return nil
+	}
+	if snapshotDryRun {
+		color.Cyan("%s: would tag %s", pkg.ModulePath, tag)
+		return nil
+	}
+
+	message := fmt.Sprintf("Snapshot %s for %s", tag, pkg.ModulePath)
+	if err := gitutils.CreateTag(pkg.Path, tag, commit, message); err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", tag, err)
+	}
+	if !snapshotNoPush {
+		for _, remote := range cfg.GetRemotes(pkg.ModulePath) {
+			if err := gitutils.PushTag(pkg.Path, remote, tag); err != nil {
+				return fmt.Errorf("failed to push tag %s to %s: %w", tag, remote, err)
+			}
+		}
+	}
+	color.Green("%s: tagged %s", pkg.ModulePath, tag)
+	return nil
+}
+
+// nextSnapshotVersion computes the version the unreleased changes of a
+// package since its latest release, tagged currentTag, lead up to
+func nextSnapshotVersion(cfg *config.Config, pkg *discovery.Package, currentTag string) (*tagutils.TagInfo, error) {
+	format := cfg.GetPackageConfig(pkg.ModulePath).TagFormat
+	packageName := tagutils.ExtractPackageNameFromModule(pkg.ModulePath)
+	layout, err := cfg.GetCalverLayout(pkg.ModulePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version scheme: %w", err)
+	}
+	versions, err := history.Versions(pkg.Path, format, packageName)
+	if err != nil {
+		return nil, err
+	}
+
+	current := tagutils.LatestStable(versions)
+	if current == nil {
+		current = &tagutils.TagInfo{PackageName: packageName, Version: "v0.0.0"}
+	}
+	if layout != "" {
+		current.SetLayout(layout)
+		return tagutils.CalculateNewVersion(current, tagutils.SchemeCalver)
+	}
+
+	// The bump policy sets the least bump, the API changes may need more
+	var versionType string
+	switch policy := cfg.GetBumpPolicy(pkg.ModulePath); policy {
+	case "major", "minor", "patch":
+		versionType = policy
+	}
+	if currentTag != "" {
+		report, err := apicompat.CheckAgainstTag(pkg.Path, currentTag)
+		if err != nil {
+			color.Yellow("%s: skipping API compatibility check: %v", pkg.ModulePath, err)
+		} else if required := report.RequiredBump(current.Major); versionType == "" || !apicompat.BumpSatisfies(versionType, required) {
+			versionType = required
+		}
+	}
+	if versionType == "" {
+		versionType = "patch"
+	}
+
+	next, err := tagutils.CalculateNewVersion(current, versionType)
+	if err != nil {
+		return nil, err
+	}
+	if inProgress := tagutils.InProgress(versions); inProgress != nil && tagutils.CompareVersions(inProgress.Final(), next) > 0 {
+		next = inProgress.Final()
+	}
+	return next, nil
+}
+
+// pruneSnapshots deletes the package's snapshot tags created before cutoff
+func pruneSnapshots(cfg *config.Config, pkg *discovery.Package, cutoff time.Time) error {
+	format := cfg.GetPackageConfig(pkg.ModulePath).TagFormat
+	packageName := tagutils.ExtractPackageNameFromModule(pkg.ModulePath)
+	tags, err := gitutils.ListTags(pkg.Path, tagutils.FormatGlob(format, packageName))
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		if _, ok := tagutils.MatchSnapshot(format, packageName, snapshotName, tag.Name); !ok || !tag.Date.Before(cutoff) {
+			continue
+		}
+		if snapshotDryRun {
+			color.Cyan("%s: would prune %s", pkg.ModulePath, tag.Name)
+			continue
+		}
+		if !snapshotNoPush {
+			for _, remote := range cfg.GetRemotes(pkg.ModulePath) {
+				if err := gitutils.DeleteRemoteTag(pkg.Path, remote, tag.Name); err != nil {
+					color.Yellow("Warning: failed to delete %s from %s: %v", tag.Name, remote, err)
+				}
+			}
+		}
+		if err := gitutils.DeleteTag(pkg.Path, tag.Name); err != nil {
+			return fmt.Errorf("failed to delete tag %s: %w", tag.Name, err)
+		}
+		color.White("%s: pruned %s", pkg.ModulePath, tag.Name)
+	}
+	return nil
+}
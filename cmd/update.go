@@ -2,13 +2,19 @@ package cmd
 
 import (
 	"fmt"
-	"os/exec"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/apicompat"
 	"github.com/gambitier/tag-manager/pkg/config"
 	"github.com/gambitier/tag-manager/pkg/discovery"
-	"github.com/gambitier/tag-manager/pkg/display"
+	"github.com/gambitier/tag-manager/pkg/gates"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/groups"
+	"github.com/gambitier/tag-manager/pkg/history"
+	"github.com/gambitier/tag-manager/pkg/hooks"
 	"github.com/gambitier/tag-manager/pkg/interactive"
 	"github.com/gambitier/tag-manager/pkg/tagutils"
 	"github.com/spf13/cobra"
@@ -22,22 +28,69 @@ var updateCmd = &cobra.Command{
 }
 
 var (
-// No flags needed - everything will be interactive
+	skipGates         bool
+	allowGateFailures bool
+	gateTimeout       time.Duration
+	apiCheck          string
+	updateFilters     []string
+	reconfigure       bool
+	releaseRef        string
+	pickCommit        bool
+	targetVersion     string
+	allowDowngrade    bool
+	releaseChannel    string
+	forceChannel      bool
 )
 
+func init() {
+	addReleaseFlags(updateCmd)
+	updateCmd.Flags().StringArrayVarP(&updateFilters, "filter", "f", nil, "Only offer packages matching a filter expression, as in list (repeatable)")
+	updateCmd.Flags().BoolVar(&reconfigure, "reconfigure", false, "Change the configuration of the selected package before releasing it")
+	updateCmd.Flags().StringVar(&releaseRef, "ref", "", "Tag this commit, branch or tag instead of HEAD")
+	updateCmd.Flags().BoolVar(&pickCommit, "pick-commit", false, "Pick the commit to tag from the recent commits touching the package")
+	updateCmd.Flags().StringVar(&targetVersion, "version", "", "Release this version (e.g. v3.0.0) instead of bumping the current one")
+	updateCmd.Flags().BoolVar(&allowDowngrade, "allow-downgrade", false, "Allow a --version or explicit version lower than the current one")
+	updateCmd.Flags().StringVar(&releaseChannel, "channel", "", "Release on a channel: alpha, beta or rc pre-releases, or final to promote the pre-release in progress")
+	updateCmd.Flags().BoolVar(&forceChannel, "force", false, "Allow --channel to move a pre-release back to a less stable channel, on the next patch version")
+	updateCmd.MarkFlagsMutuallyExclusive("ref", "pick-commit")
+	updateCmd.MarkFlagsMutuallyExclusive("version", "channel")
+}
+
+// addReleaseFlags registers the flags controlling the tag flow on commands
+// that release a package
+func addReleaseFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&skipGates, "skip-gates", false, "Skip the pre-tag quality gates")
+	cmd.Flags().BoolVar(&allowGateFailures, "allow-gate-failures", false, "Create the tag even if quality gates fail")
+	cmd.Flags().DurationVar(&gateTimeout, "gate-timeout", 0, "Maximum duration of a single quality gate (overrides config)")
+	cmd.Flags().StringVar(&apiCheck, "api-check", "warn", "Exported API compatibility check: warn, block or off")
+}
+
 func runUpdate(cmd *cobra.Command, args []string) error {
+	if err := validateReleaseFlags(); err != nil {
+		return err
+	}
+	if releaseChannel != "" {
+		if err := tagutils.ValidateChannel(releaseChannel); err != nil {
+			return fmt.Errorf("invalid --channel: %w", err)
+		}
+	}
+	filter, err := discovery.ParseFilter(strings.Join(updateFilters, ","))
+	if err != nil {
+		return err
+	}
+
 	// Load configuration
 	configPath := config.GetConfigPath()
-	cfg, err := config.LoadConfig(configPath)
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
 	// Discover packages
 	searchPaths := discovery.GetDefaultSearchPaths()
-	packages, err := discovery.DiscoverPackages(searchPaths)
+	packages, err := discoverPackages(cfg, searchPaths)
 	if err != nil {
-		return fmt.Errorf("failed to discover packages: %w", err)
+		return err
 	}
 
 	if len(packages) == 0 {
@@ -46,19 +99,78 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Display available packages
-	color.Cyan("Available packages:")
-	display.ShowPackageList(packages, display.Compact)
-	color.White("")
+	packages = filter.Apply(packages)
+	if len(packages) == 0 {
+		color.Yellow("No packages match the filter.")
+		return nil
+	}
 
-	// Let user select a package
-	selectedPackage, err := interactive.SelectPackage(packages)
+	// Let user select packages
+	prompter := newPrompter(cmd)
+	selected, err := interactive.SelectPackages(prompter, packages)
 	if err != nil {
-		return fmt.Errorf("failed to select package: %w", err)
+		return handleCancel(fmt.Errorf("failed to select package: %w", err))
+	}
+
+	releasedGroups := make(map[string]bool)
+	for i := range selected {
+		// Members of a version group are released together
+		if group := cfg.GroupOf(selected[i].ModulePath); group != nil {
+			if releasedGroups[group.Name] {
+				continue
+			}
+			releasedGroups[group.Name] = true
+		}
+		if len(selected) > 1 {
+			color.Cyan("\n=== Releasing %s (%d of %d) ===", selected[i].ModulePath, i+1, len(selected))
+		}
+		if err := releasePackage(prompter, cfg, configPath, &selected[i], ""); err != nil {
+			return handleCancel(err)
+		}
+	}
+	return nil
+}
+
+// validateReleaseFlags checks the values of the flags added by addReleaseFlags
+func validateReleaseFlags() error {
+	switch apiCheck {
+	case "warn", "block", "off":
+		return nil
+	default:
+		return fmt.Errorf("invalid --api-check value %q: must be warn, block or off", apiCheck)
+	}
+}
+
+// releasePackage runs the tag flow for a package: configuration, version
+// selection, quality gates, confirmation and tagging. An empty versionType
+// lets the user select it interactively. Members of a version group are
+// released together with the rest of the group.
+func releasePackage(prompter interactive.Prompter, cfg *config.Config, configPath string, selectedPackage *discovery.Package, versionType string) error {
+	if cfg.GroupOf(selectedPackage.ModulePath) != nil {
+		packages, err := discoverPackages(cfg, discovery.GetDefaultSearchPaths())
+		if err != nil {
+			return err
+		}
+		group, err := groups.Find(cfg, packages, selectedPackage.ModulePath)
+		if err != nil {
+			return err
+		}
+		return releaseGroup(prompter, cfg, configPath, group, selectedPackage, versionType)
+	}
+
+	// Resolve the commit to tag
+	commit, err := releaseCommit(prompter, cfg, selectedPackage)
+	if err != nil {
+		return err
 	}
 
 	// Setup package configuration if needed
-	pkgConfig, err := interactive.SetupPackageConfig(cfg, *selectedPackage)
+	var pkgConfig *config.PackageConfig
+	if reconfigure {
+		pkgConfig, err = interactive.ConfigurePackage(prompter, cfg, *selectedPackage)
+	} else {
+		pkgConfig, err = interactive.SetupPackageConfig(prompter, cfg, *selectedPackage)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to setup package configuration: %w", err)
 	}
@@ -68,36 +180,134 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		color.Yellow("Warning: failed to save configuration: %v", err)
 	}
 
-	// Let user select version type
-	versionType, err := interactive.SelectVersionType()
-	if err != nil {
-		return fmt.Errorf("failed to select version type: %w", err)
+	// Gates and the API check run against the commit to tag
+	releasedPackage := selectedPackage
+	if head, err := gitutils.ResolveCommit(selectedPackage.Path, "HEAD"); err != nil || head != commit.Hash {
+		checkedOut, cleanup, err := checkoutCommit(selectedPackage, commit.Hash)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		releasedPackage = checkedOut
 	}
 
-	// Get current tag
-	currentTag, err := getCurrentTag(selectedPackage.ModulePath, pkgConfig.TagFormat)
+	// The current version is the latest stable release among the tags the
+	// package's format produces; pre-releases and snapshots don't count
+	packageName := tagutils.ExtractPackageNameFromModule(selectedPackage.ModulePath)
+	versions, err := history.Versions(selectedPackage.Path, pkgConfig.TagFormat, packageName)
+	if err != nil {
+		return fmt.Errorf("failed to list versions of %s: %w", selectedPackage.ModulePath, err)
+	}
+	currentTag, err := history.LatestStableTag(selectedPackage.Path, pkgConfig.TagFormat, packageName)
 	if err != nil {
 		return fmt.Errorf("failed to get current tag: %w", err)
 	}
+	currentTagInfo := tagutils.LatestStable(versions)
+	if currentTagInfo == nil {
+		currentTagInfo = &tagutils.TagInfo{PackageName: packageName, Version: "v0.0.0"}
+	}
 
-	// Parse current tag
-	currentTagInfo, err := tagutils.ParseTag(currentTag)
-	if err != nil {
-		// If we can't parse the current tag, start from v0.0.0
-		packageName := tagutils.ExtractPackageNameFromModule(selectedPackage.ModulePath)
-		currentTagInfo = &tagutils.TagInfo{
-			PackageName: packageName,
-			Major:       0,
-			Minor:       0,
-			Patch:       0,
-			Version:     "v0.0.0",
+	if releaseRef != "" || pickCommit {
+		if err := confirmReleasePosition(prompter, selectedPackage, commit, pkgConfig.TagFormat, currentTag); err != nil {
+			return err
 		}
 	}
 
-	// Calculate new version
-	newVersion, err := tagutils.CalculateNewVersion(currentTagInfo, versionType)
+	// Calendar versions render and bump by the package's layout
+	layout, err := cfg.GetCalverLayout(selectedPackage.ModulePath)
 	if err != nil {
-		return fmt.Errorf("failed to calculate new version: %w", err)
+		return fmt.Errorf("invalid version scheme of %s: %w", selectedPackage.ModulePath, err)
+	}
+	if layout != "" {
+		if releaseChannel != "" {
+			return fmt.Errorf("--channel can't be used with the calendar versions of %s", selectedPackage.ModulePath)
+		}
+		currentTagInfo.SetLayout(layout)
+	}
+
+	// Compare the exported API against the current tag to suggest a bump;
+	// calendar versions say nothing about compatibility
+	var apiReport *apicompat.Report
+	if apiCheck != "off" && currentTag != "" && layout == "" {
+		apiReport = checkAPI(releasedPackage, currentTag, currentTagInfo.Major)
+	}
+
+	// A release without a current tag has no version to compare against
+	var currentVersion *tagutils.TagInfo
+	if currentTag != "" {
+		currentVersion = currentTagInfo
+	}
+	validate := func(target *tagutils.TagInfo) error {
+		return validateTargetVersion(target, currentVersion, selectedPackage, pkgConfig.TagFormat)
+	}
+
+	// Continue the release cycle in progress on the channel, or use the
+	// calendar, the explicit version, the package's bump policy, or let user
+	// select version type
+	var newVersion *tagutils.TagInfo
+	if releaseChannel != "" {
+		if newVersion, err = continueChannel(versions); err != nil {
+			return err
+		}
+	}
+	if newVersion != nil {
+		versionType = releaseChannel
+	} else if layout != "" {
+		if targetVersion != "" {
+			return fmt.Errorf("--version can't be used with the calendar versions of %s", selectedPackage.ModulePath)
+		}
+		versionType = tagutils.SchemeCalver
+	} else if targetVersion != "" {
+		newVersion, err = tagutils.ParseVersion(targetVersion)
+		if err != nil {
+			return err
+		}
+		if err := validate(newVersion); err != nil {
+			return err
+		}
+		versionType = interactive.VersionExplicit
+	}
+	if versionType == "" {
+		versionType = versionTypeFromPolicy(cfg, selectedPackage.ModulePath, apiReport, currentTagInfo.Major)
+	}
+	if versionType == "" {
+		versionType, err = interactive.SelectVersionType(prompter)
+		if err != nil {
+			return fmt.Errorf("failed to select version type: %w", err)
+		}
+	}
+
+	if versionType == interactive.VersionExplicit {
+		if newVersion == nil {
+			newVersion, err = interactive.AskForVersion(prompter, validate)
+			if err != nil {
+				return err
+			}
+		}
+		warnVersionJump(newVersion, currentVersion)
+		newVersion = startChannel(versions, newVersion)
+	} else if versionType != releaseChannel {
+		// Calculate new version
+		newVersion, err = tagutils.CalculateNewVersion(currentTagInfo, versionType)
+		if err != nil {
+			return fmt.Errorf("failed to calculate new version: %w", err)
+		}
+		newVersion = startChannel(versions, newVersion)
+	}
+
+	// Explicit and channel versions are checked like the bump they amount to
+	bump := versionType
+	if versionType == interactive.VersionExplicit || releaseChannel != "" {
+		bump = tagutils.VersionType(currentTagInfo, newVersion)
+	}
+	if apiReport != nil && bump != "" {
+		required := apiReport.RequiredBump(currentTagInfo.Major)
+		if !apicompat.BumpSatisfies(bump, required) {
+			if apiCheck == "block" {
+				return fmt.Errorf("a %s release is too small for the API changes since %s; at least %s is required", bump, currentTag, required)
+			}
+			color.Yellow("Warning: a %s release is too small for the API changes since %s; at least %s is recommended", bump, currentTag, required)
+		}
 	}
 
 	// Format new tag
@@ -108,68 +318,216 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	color.White("Package: %s", selectedPackage.ModulePath)
 	color.White("Package Name: %s", selectedPackage.PackageName)
 	color.White("Tag Format: %s", pkgConfig.TagFormat)
-	color.Yellow("Current tag: %s", currentTag)
+	if currentTag == "" {
+		color.Yellow("Current tag: (no tags)")
+	} else {
+		color.Yellow("Current tag: %s", currentTag)
+	}
 	color.Cyan("New tag: %s", newTag)
+	warnModuleVersion(selectedPackage, newVersion)
 	color.Cyan("Version type: %s", versionType)
+	if releaseChannel != "" {
+		color.Cyan("Channel: %s", releaseChannel)
+	}
+	color.White("Commit: %s %s", commit.ShortHash, commit.Subject)
+
+	// Run quality gates before asking for confirmation
+	if !skipGates {
+		if err := runGates(cfg, releasedPackage); err != nil {
+			return err
+		}
+	}
 
 	// Ask for confirmation
-	if !interactive.AskForConfirmation("Do you want to update the tag?") {
+	confirmed, err := interactive.AskForConfirmation(prompter, "Do you want to update the tag?")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
 		color.Yellow("Tag update cancelled.")
 		return nil
 	}
 
+	hookConfig := cfg.GetHooks(selectedPackage.ModulePath)
+	release := hooks.Release{
+		ModulePath: selectedPackage.ModulePath,
+		Path:       selectedPackage.Path,
+		Tag:        newTag,
+		Version:    newVersion.Version,
+	}
+	if len(hookConfig.PreTag) > 0 {
+		color.Cyan("\n=== Running pre-tag hooks ===")
+		if err := hooks.Run(hookConfig.PreTag, release, os.Stdout); err != nil {
+			return fmt.Errorf("pre-tag hook failed, tag not created: %w", err)
+		}
+	}
+
 	// Update the tag
-	if err := updateTag(selectedPackage.ModulePath, newTag); err != nil {
+	remotes := cfg.GetRemotes(selectedPackage.ModulePath)
+	message := tagutils.FormatTagMessage(cfg.GetTagMessage(selectedPackage.ModulePath), newTag, selectedPackage.ModulePath, *newVersion)
+	if err := updateTag(selectedPackage.Path, newTag, commit.Hash, message, remotes); err != nil {
 		return fmt.Errorf("failed to update tag: %w", err)
 	}
+	recordRelease(cfg, configPath, selectedPackage, newTag, remotes)
 
 	color.Green("Successfully updated tag to %s for package %s", newTag, selectedPackage.ModulePath)
+
+	if len(hookConfig.PostTag) > 0 {
+		color.Cyan("\n=== Running post-tag hooks ===")
+		if err := hooks.Run(hookConfig.PostTag, release, os.Stdout); err != nil {
+			color.Yellow("Warning: %v", err)
+		}
+	}
 	return nil
 }
 
-func getCurrentTag(modulePath, tagFormat string) (string, error) {
-	// Try to find existing tags that match the expected format
-	// We'll search for tags that could match our format
-	packageName := tagutils.ExtractPackageNameFromModule(modulePath)
+// versionTypeFromPolicy returns the version type set by the package's bump
+// policy, or an empty string when the user should select it
+func versionTypeFromPolicy(cfg *config.Config, modulePath string, apiReport *apicompat.Report, currentMajor int) string {
+	switch policy := cfg.GetBumpPolicy(modulePath); policy {
+	case config.BumpAsk:
+		return ""
+	case config.BumpAPI:
+		if apiReport == nil {
+			color.Yellow("Bump policy is %s but no API comparison is available; select the version type", policy)
+			return ""
+		}
+		versionType := apiReport.RequiredBump(currentMajor)
+		color.Cyan("Using version type %s required by the API changes (bump policy %s)", versionType, policy)
+		return versionType
+	default:
+		color.Cyan("Using version type %s from the bump policy", policy)
+		return policy
+	}
+}
 
-	// Try different tag patterns
-	patterns := []string{
-		fmt.Sprintf("%s/*", packageName),
-		fmt.Sprintf("%s-*", packageName),
-		"v*",
-		"*",
+// checkAPI compares the package's exported API at currentTag with the working
+// tree and prints the report, with the version type it requires of a release
+// following currentMajor. It returns nil if the comparison isn't possible.
+func checkAPI(pkg *discovery.Package, currentTag string, currentMajor int) *apicompat.Report {
+	color.Cyan("\nChecking exported API against %s...", currentTag)
+	report, err := apicompat.CheckAgainstTag(pkg.Path, currentTag)
+	if err != nil {
+		color.Yellow("Warning: skipping API compatibility check: %v", err)
+		return nil
 	}
 
-	for _, pattern := range patterns {
-		cmd := exec.Command("git", "tag", "--list", pattern, "--sort=-version:refname")
-		output, err := cmd.Output()
+	incompatible := report.Incompatible()
+	compatible := report.Compatible()
+	if len(incompatible) == 0 && len(compatible) == 0 {
+		color.Green("No exported API changes since %s", currentTag)
+	}
+	if len(incompatible) > 0 {
+		color.Red("Incompatible changes (%d):", len(incompatible))
+		for _, change := range incompatible {
+			color.Red("  - %s", change)
+		}
+	}
+	if len(compatible) > 0 {
+		color.Green("Compatible additions (%d):", len(compatible))
+		for _, change := range compatible {
+			color.Green("  + %s", change)
+		}
+	}
+
+	color.Cyan("Minimum required version type: %s", report.RequiredBump(currentMajor))
+
+	return report
+}
+
+// runGates runs the package's pre-tag quality gates and reports the results.
+// It returns an error when a gate fails and failures are not allowed.
+func runGates(cfg *config.Config, pkg *discovery.Package) error {
+	gateConfig := cfg.GetGateConfig(pkg.ModulePath)
+	pkgGates := gates.FromConfig(gateConfig)
+	if len(pkgGates) == 0 {
+		color.Yellow("Quality gates: disabled")
+		return nil
+	}
+
+	timeout := gateTimeout
+	if timeout <= 0 {
+		var err error
+		timeout, err = gateConfig.GateTimeout()
 		if err != nil {
-			continue
+			return err
 		}
+	}
+
+	color.Cyan("\n=== Running Quality Gates in %s ===", pkg.Path)
+	results := gates.Run(pkg.Path, pkgGates, timeout, os.Stdout)
 
-		tags := strings.Split(strings.TrimSpace(string(output)), "\n")
-		if len(tags) > 0 && tags[0] != "" {
-			// Return the most recent tag
-			return tags[0], nil
+	color.Cyan("\nQuality gates:")
+	for _, result := range results {
+		if result.Passed {
+			color.Green("  ✓ %s (%s)", result.Gate.Name, result.Duration.Round(time.Millisecond))
+		} else {
+			color.Red("  ✗ %s (%s): %v", result.Gate.Name, result.Duration.Round(time.Millisecond), result.Err)
 		}
 	}
 
-	// If no tags found, return empty string (will be handled as v0.0.0)
-	return "", nil
+	if !gates.AllPassed(results) {
+		if allowGateFailures {
+			color.Yellow("Warning: quality gates failed, continuing because --allow-gate-failures is set")
+			return nil
+		}
+		return fmt.Errorf("quality gates failed; fix the failures or re-run with --allow-gate-failures")
+	}
+
+	return nil
 }
 
-func updateTag(modulePath, newTag string) error {
-	// Run git tag command with annotated tag and message
-	cmd := exec.Command("git", "tag", "-a", newTag, "-m", fmt.Sprintf("Release %s for %s", newTag, modulePath))
-	if err := cmd.Run(); err != nil {
+func updateTag(dir, newTag, commit, message string, remotes []string) error {
+	// Create an annotated tag with a message
+	if err := gitutils.CreateTag(dir, newTag, commit, message); err != nil {
 		return fmt.Errorf("failed to create git tag: %w", err)
 	}
 
-	// Push the tag
-	pushCmd := exec.Command("git", "push", "origin", newTag)
-	if err := pushCmd.Run(); err != nil {
-		return fmt.Errorf("failed to push git tag: %w", err)
+	// Push the tag to every configured remote
+	for _, remote := range remotes {
+		if err := gitutils.PushTag(dir, remote, newTag); err != nil {
+			return fmt.Errorf("failed to push git tag to %s: %w", remote, err)
+		}
 	}
 
 	return nil
 }
+
+// recordRelease remembers the release so it can be reverted with undo
+func recordRelease(cfg *config.Config, configPath string, pkg *discovery.Package, tag string, remotes []string) {
+	record, err := newReleaseRecord(pkg, tag, remotes, time.Now().Format(time.RFC3339))
+	if err != nil {
+		color.Yellow("Warning: failed to record release: %v", err)
+		return
+	}
+	saveRelease(cfg, configPath, record)
+}
+
+// newReleaseRecord describes the release of tag for pkg
+func newReleaseRecord(pkg *discovery.Package, tag string, remotes []string, createdAt string) (*config.ReleaseRecord, error) {
+	commit, err := gitutils.ResolveCommit(pkg.Path, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &config.ReleaseRecord{
+		ModulePath: pkg.ModulePath,
+		Path:       pkg.Path,
+		Tag:        tag,
+		Commit:     commit,
+		Remotes:    remotes,
+		CreatedAt:  createdAt,
+	}, nil
+}
+
+// saveRelease stores record as the last release and marks its packages as
+// released
+func saveRelease(cfg *config.Config, configPath string, record *config.ReleaseRecord) {
+	cfg.LastRelease = record
+	for _, release := range record.Releases() {
+		cfg.TouchPackage(release.ModulePath, record.CreatedAt)
+	}
+
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		color.Yellow("Warning: failed to save configuration: %v", err)
+	}
+}
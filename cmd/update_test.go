@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gambitier/tag-manager/internal/gittest"
+)
+
+// setupUpdateRepo creates a repository with a single module and a remote to
+// push tags to, changes into it and returns its directory
+func setupUpdateRepo(t *testing.T) string {
+	t.Helper()
+	gittest.Setup(t)
+
+	dir := t.TempDir()
+	remote := t.TempDir()
+	t.Setenv("TAG_MANAGER_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv("TAG_MANAGER_TAG_FORMAT", "")
+	t.Setenv("TAG_MANAGER_REMOTES", "")
+
+	files := map[string]string{
+		"go.mod":    "module example.com/widget\n\ngo 1.21\n",
+		"widget.go": "package widget\n\n// Name returns the name of the widget\nfunc Name() string { return \"widget\" }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gittest.Run(t, remote, "init", "--bare", "--quiet")
+	gittest.Run(t, dir, "init", "--quiet")
+	gittest.Run(t, dir, "add", ".")
+	gittest.Run(t, dir, "commit", "--quiet", "-m", "Initial commit")
+	gittest.Run(t, dir, "remote", "add", "origin", remote)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// Gates would build and test the module, which isn't what these tests are about
+	savedSkipGates, savedAPICheck, savedFilters := skipGates, apiCheck, updateFilters
+	skipGates, apiCheck, updateFilters = true, "warn", nil
+	t.Cleanup(func() { skipGates, apiCheck, updateFilters = savedSkipGates, savedAPICheck, savedFilters })
+	return dir
+}
+
+// runScriptedUpdate runs the update command answering its prompts with script
+func runScriptedUpdate(t *testing.T, script string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	updateCmd.SetIn(strings.NewReader(script))
+	updateCmd.SetOut(&out)
+	t.Cleanup(func() {
+		updateCmd.SetIn(nil)
+		updateCmd.SetOut(nil)
+	})
+
+	err := runUpdate(updateCmd, nil)
+	return out.String(), err
+}
+
+func TestRunUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		// tag is the tag expected to be created, if any
+		tag string
+		// calver is the time layout of the year and month of the calendar
+		// version expected to be tagged at the time of the run
+		calver  string
+		wantErr string
+		// prompts are messages expected in the output
+		prompts []string
+	}{
+		{
+			name:   "select by number",
+			script: "1\n1\n1\ny\n2\ny\n",
+			tag:    "widget/v0.1.0",
+		},
+		{
+			name:   "select by name",
+			script: "widget\n1\n1\ny\n3\ny\n",
+			tag:    "widget/v0.0.1",
+		},
+		{
+			name:    "re-prompt after invalid answers",
+			script:  "7\nwidget\nthree\n1\n1\nmaybe\ny\n1\ny",
+			tag:     "widget/v1.0.0",
+			prompts: []string{"between 1 and 1", `invalid input "three"`, "please answer y or n"},
+		},
+		{
+			name:    "custom tag format",
+			script:  "1\n2\n\n{version\nrelease-{version}\n1\ny\n3\ny\n",
+			tag:     "release-v0.0.1",
+			prompts: []string{"tag format cannot be empty", "invalid format"},
+		},
+		{
+			name:    "calendar version",
+			script:  "1\n1\n2\nYYYY.MM\n\ny\ny\n",
+			calver:  "2006.1",
+			prompts: []string{"expected three segments"},
+		},
+		{
+			name:   "declined",
+			script: "1\n1\n1\ny\n3\nn\n",
+		},
+		{
+			name:   "cancelled at end of input",
+			script: "1\n1\n",
+		},
+		{
+			name:    "too many invalid answers",
+			script:  "x\nx\nx\n",
+			wantErr: "no valid answer after 3 attempts",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := setupUpdateRepo(t)
+
+			start := time.Now().UTC()
+			out, err := runScriptedUpdate(t, test.script)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("runUpdate() error = %v, want %q", err, test.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("runUpdate() error = %v\noutput:\n%s", err, out)
+			}
+			for _, prompt := range test.prompts {
+				if !strings.Contains(out, prompt) {
+					t.Errorf("output does not contain %q:\n%s", prompt, out)
+				}
+			}
+
+			tags := gittest.Run(t, dir, "tag", "--list")
+			if test.calver != "" {
+				// The month may roll over during the run
+				test.tag = "widget/v" + start.Format(test.calver) + ".0"
+				if tags != test.tag {
+					test.tag = "widget/v" + time.Now().UTC().Format(test.calver) + ".0"
+				}
+			}
+			if tags != test.tag {
+				t.Errorf("tags = %q, want %q", tags, test.tag)
+			}
+			if test.tag != "" {
+				if remoteTags := gittest.Run(t, dir, "ls-remote", "--tags", "origin"); !strings.Contains(remoteTags, "refs/tags/"+test.tag) {
+					t.Errorf("tag %s was not pushed: %q", test.tag, remoteTags)
+				}
+			}
+		})
+	}
+}
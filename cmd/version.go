@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+)
+
+// validateTargetVersion checks an explicitly chosen version against the
+// current version of a package, the major version suffix of its module path
+// and the existing tags. current is nil when the package has no release yet.
+func validateTargetVersion(target, current *tagutils.TagInfo, pkg *discovery.Package, tagFormat string) error {
+	modulePath := pkg.ModulePath
+	if err := tagutils.CheckModuleVersion(modulePath, target.Version); err != nil {
+		return err
+	}
+
+	if current != nil {
+		switch cmp := tagutils.CompareVersions(target, current); {
+		case cmp == 0:
+			return fmt.Errorf("%s is the current version", target.Version)
+		case cmp < 0 && !allowDowngrade:
+			return fmt.Errorf("%s is lower than the current version %s; use --allow-downgrade to release it anyway", target.Version, current.Version)
+		}
+	}
+
+	target.PackageName = tagutils.ExtractPackageNameFromModule(modulePath)
+	if tag := tagutils.FormatTag(tagFormat, *target); gitutils.TagExists(pkg.Path, tag) {
+		return fmt.Errorf("tag %s already exists", tag)
+	}
+	return nil
+}
+
+// warnModuleVersion warns when the go command can't use a calendar version
+// as a version of the package's module
+func warnModuleVersion(pkg *discovery.Package, version *tagutils.TagInfo) {
+	if version.Layout == "" {
+		return
+	}
+	if err := tagutils.CheckModuleVersion(pkg.ModulePath, version.Version); err != nil {
+		color.Yellow("Warning: go get can't fetch %s of %s: %v", version.Version, pkg.ModulePath, err)
+	}
+}
+
+// warnVersionJump warns when an explicitly chosen version skips versions
+// after the current one, or is lower than it
+func warnVersionJump(target, current *tagutils.TagInfo) {
+	if current == nil {
+		return
+	}
+
+	versionType := tagutils.VersionType(current, target)
+	if versionType == "" {
+		color.Yellow("Warning: %s is lower than the current version %s", target.Version, current.Version)
+		return
+	}
+	next, err := tagutils.CalculateNewVersion(current, versionType)
+	if err == nil && tagutils.CompareVersions(target, next) != 0 {
+		color.Yellow("Warning: %s skips versions; the next %s release after %s would be %s", target.Version, versionType, current.Version, next.Version)
+	}
+}
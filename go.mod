@@ -6,6 +6,10 @@ require (
 	github.com/fatih/color v1.16.0
 	github.com/olekukonko/tablewriter v1.1.0
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/mod v0.24.0
+	golang.org/x/sys v0.31.0
+	golang.org/x/term v0.30.0
+	golang.org/x/tools v0.31.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -18,5 +22,5 @@ require (
 	github.com/olekukonko/ll v0.0.9 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/sync v0.12.0 // indirect
 )
@@ -0,0 +1,49 @@
+package gittest
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// Setup skips the test when git isn't installed and sets the identity
+// commits and tags are made with
+func Setup(t testing.TB) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+}
+
+// Run runs a git command in dir and returns its output
+func Run(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// NewRepo creates a repository with an empty commit for each message and
+// returns its directory and the hashes of the commits, oldest first
+func NewRepo(t testing.TB, messages ...string) (string, []string) {
+	t.Helper()
+	Setup(t)
+
+	dir := t.TempDir()
+	Run(t, dir, "init", "--quiet")
+	var commits []string
+	for _, message := range messages {
+		Run(t, dir, "commit", "--quiet", "--allow-empty", "-m", message)
+		commits = append(commits, Run(t, dir, "rev-parse", "HEAD"))
+	}
+	return dir, commits
+}
@@ -0,0 +1,366 @@
+package apicompat
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"golang.org/x/tools/go/packages"
+)
+
+// ChangeKind represents the kind of an API change
+type ChangeKind int
+
+const (
+	// Removed means an exported identifier no longer exists
+	Removed ChangeKind = iota
+	// Changed means an exported identifier has a different type or signature
+	Changed
+	// Added means a new exported identifier was introduced
+	Added
+)
+
+// String returns a human readable name for the change kind
+func (k ChangeKind) String() string {
+	switch k {
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	case Added:
+		return "added"
+	default:
+		return "unknown"
+	}
+}
+
+// Change represents a single difference in the exported API
+type Change struct {
+	Kind       ChangeKind
+	Package    string
+	Name       string
+	Old        string
+	New        string
+	Compatible bool
+}
+
+// Report represents the differences between two versions of a module's API
+type Report struct {
+	Changes []Change
+}
+
+// API represents the exported API of a module
+type API struct {
+	packages map[string]map[string]element
+}
+
+// element represents a single exported identifier
+type element struct {
+	desc string
+	// implementable is set for methods of interfaces that other packages
+	// can implement, where adding a method breaks existing implementations
+	implementable bool
+}
+
+// CheckAgainstTag compares the exported API of the module in pkgDir at tag
+// against its working tree
+func CheckAgainstTag(pkgDir, tag string) (*Report, error) {
+	root, err := gitutils.RepoRoot(pkgDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repository root: %w", err)
+	}
+
+	absDir, err := filepath.Abs(pkgDir)
+	if err != nil {
+		return nil, err
+	}
+	// Resolve symlinks so the path is comparable with the git root
+	if resolved, err := filepath.EvalSymlinks(absDir); err == nil {
+		absDir = resolved
+	}
+	rel, err := filepath.Rel(root, absDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve module directory: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "tag-manager-api-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := gitutils.ExtractTree(root, tag, rel, tmpDir); err != nil {
+		return nil, fmt.Errorf("failed to read module at %s: %w", tag, err)
+	}
+
+	oldAPI, err := LoadAPI(filepath.Join(tmpDir, rel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API at %s: %w", tag, err)
+	}
+
+	newAPI, err := LoadAPI(pkgDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API of working tree: %w", err)
+	}
+
+	return Compare(oldAPI, newAPI), nil
+}
+
+// LoadAPI loads the exported API of all non-internal packages of the module in dir
+func LoadAPI(dir string) (*API, error) {
+	// Type-check from source rather than compiler export data so the result
+	// doesn't depend on the installed toolchain's export format
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps,
+		Dir:  dir,
+		Env:  append(os.Environ(), "GOWORK=off"),
+	}
+
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, err
+	}
+
+	api := &API{packages: make(map[string]map[string]element)}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("package %s: %v", pkg.PkgPath, pkg.Errors[0])
+		}
+		if isInternal(pkg.PkgPath) || pkg.Name == "main" || pkg.Types == nil {
+			continue
+		}
+		api.packages[pkg.PkgPath] = packageElements(pkg.Types)
+	}
+
+	return api, nil
+}
+
+// Compare reports the differences between two APIs
+func Compare(oldAPI, newAPI *API) *Report {
+	report := &Report{}
+
+	for pkgPath, oldElems := range oldAPI.packages {
+		newElems, exists := newAPI.packages[pkgPath]
+		if !exists {
+			report.Changes = append(report.Changes, Change{Kind: Removed, Package: pkgPath, Old: "package"})
+			continue
+		}
+
+		for name, oldElem := range oldElems {
+			newElem, exists := newElems[name]
+			switch {
+			case !exists:
+				report.Changes = append(report.Changes, Change{Kind: Removed, Package: pkgPath, Name: name, Old: oldElem.desc})
+			case newElem.desc != oldElem.desc:
+				report.Changes = append(report.Changes, Change{Kind: Changed, Package: pkgPath, Name: name, Old: oldElem.desc, New: newElem.desc})
+			}
+		}
+
+		for name, newElem := range newElems {
+			if _, exists := oldElems[name]; !exists {
+				report.Changes = append(report.Changes, Change{
+					Kind:       Added,
+					Package:    pkgPath,
+					Name:       name,
+					New:        newElem.desc,
+					Compatible: !newElem.implementable,
+				})
+			}
+		}
+	}
+
+	for pkgPath := range newAPI.packages {
+		if _, exists := oldAPI.packages[pkgPath]; !exists {
+			report.Changes = append(report.Changes, Change{Kind: Added, Package: pkgPath, New: "package", Compatible: true})
+		}
+	}
+
+	sort.Slice(report.Changes, func(i, j int) bool {
+		a, b := report.Changes[i], report.Changes[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Name < b.Name
+	})
+
+	return report
+}
+
+// Incompatible returns the changes that break existing users
+func (r *Report) Incompatible() []Change {
+	var changes []Change
+	for _, change := range r.Changes {
+		if !change.Compatible {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// Compatible returns the backwards compatible additions
+func (r *Report) Compatible() []Change {
+	var changes []Change
+	for _, change := range r.Changes {
+		if change.Compatible {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// RequiredBump returns the minimum version type ("major", "minor" or "patch")
+// for the changes in the report. Modules at major version 0 may break
+// compatibility in minor releases.
+func (r *Report) RequiredBump(currentMajor int) string {
+	switch {
+	case len(r.Incompatible()) > 0:
+		if currentMajor == 0 {
+			return "minor"
+		}
+		return "major"
+	case len(r.Compatible()) > 0:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// BumpSatisfies reports whether the selected version type is at least the
+// required one
+func BumpSatisfies(selected, required string) bool {
+	return bumpRank(selected) >= bumpRank(required)
+}
+
+// bumpRank orders version types from smallest to largest
+func bumpRank(versionType string) int {
+	switch versionType {
+	case "major":
+		return 3
+	case "minor":
+		return 2
+	case "patch":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// String returns a human readable description of the change
+func (c Change) String() string {
+	name := c.Package
+	if c.Name != "" {
+		name = fmt.Sprintf("%s.%s", c.Package, c.Name)
+	}
+
+	switch c.Kind {
+	case Removed:
+		return fmt.Sprintf("%s: %s removed", name, c.Old)
+	case Changed:
+		return fmt.Sprintf("%s: changed from %s to %s", name, c.Old, c.New)
+	default:
+		return fmt.Sprintf("%s: %s added", name, c.New)
+	}
+}
+
+// isInternal reports whether a package path is internal to its module
+func isInternal(pkgPath string) bool {
+	for _, part := range strings.Split(pkgPath, "/") {
+		if part == "internal" {
+			return true
+		}
+	}
+	return false
+}
+
+// packageElements collects the exported identifiers of a package
+func packageElements(pkg *types.Package) map[string]element {
+	elems := make(map[string]element)
+	qualifier := types.RelativeTo(pkg)
+	scope := pkg.Scope()
+
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+
+		switch obj := obj.(type) {
+		case *types.Const:
+			elems[name] = element{desc: fmt.Sprintf("const %s = %s", types.TypeString(obj.Type(), qualifier), obj.Val().ExactString())}
+		case *types.Var:
+			elems[name] = element{desc: "var " + types.TypeString(obj.Type(), qualifier)}
+		case *types.Func:
+			elems[name] = element{desc: "func" + strings.TrimPrefix(types.TypeString(obj.Type(), qualifier), "func")}
+		case *types.TypeName:
+			typeElements(elems, obj, qualifier)
+		}
+	}
+
+	return elems
+}
+
+// typeElements collects a type declaration together with its exported
+// fields and methods
+func typeElements(elems map[string]element, obj *types.TypeName, qualifier types.Qualifier) {
+	name := obj.Name()
+
+	if obj.IsAlias() {
+		elems[name] = element{desc: "type = " + types.TypeString(obj.Type(), qualifier)}
+		return
+	}
+
+	switch underlying := obj.Type().Underlying().(type) {
+	case *types.Struct:
+		elems[name] = element{desc: "type struct"}
+		for i := 0; i < underlying.NumFields(); i++ {
+			field := underlying.Field(i)
+			if field.Exported() {
+				elems[name+"."+field.Name()] = element{desc: "field " + types.TypeString(field.Type(), qualifier)}
+			}
+		}
+	case *types.Interface:
+		elems[name] = element{desc: "type interface"}
+		implementable := true
+		for i := 0; i < underlying.NumMethods(); i++ {
+			if !underlying.Method(i).Exported() {
+				implementable = false
+			}
+		}
+		for i := 0; i < underlying.NumMethods(); i++ {
+			method := underlying.Method(i)
+			if method.Exported() {
+				elems[name+"."+method.Name()] = element{
+					desc:          "method" + strings.TrimPrefix(types.TypeString(method.Type(), qualifier), "func"),
+					implementable: implementable,
+				}
+			}
+		}
+		return
+	default:
+		elems[name] = element{desc: "type " + types.TypeString(underlying, qualifier)}
+	}
+
+	// Record exported methods, including whether they need a pointer receiver
+	methods := types.NewMethodSet(types.NewPointer(obj.Type()))
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.At(i).Obj().(*types.Func)
+		if !method.Exported() {
+			continue
+		}
+		sig := method.Type().(*types.Signature)
+		receiver := name
+		if _, isPointer := sig.Recv().Type().(*types.Pointer); isPointer {
+			receiver = "*" + name
+		}
+		elems[name+"."+method.Name()] = element{
+			desc: fmt.Sprintf("method (%s) %s", receiver, strings.TrimPrefix(types.TypeString(sig, qualifier), "func")),
+		}
+	}
+}
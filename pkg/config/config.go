@@ -4,96 +4,199 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/gambitier/tag-manager/pkg/tagutils"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the tag manager configuration
 type Config struct {
-	Packages map[string]PackageConfig `yaml:"packages"`
-	Defaults DefaultConfig            `yaml:"defaults"`
+	SchemaVersion int                      `yaml:"schema_version"`
+	Packages      map[string]PackageConfig `yaml:"packages"`
+	Defaults      DefaultConfig            `yaml:"defaults"`
+	Rules         []Rule                   `yaml:"rules,omitempty"`
+	Groups        []VersionGroup           `yaml:"groups,omitempty"`
+	LastRelease   *ReleaseRecord           `yaml:"last_release,omitempty"`
+
+	// file is the layer written by SaveConfig when the configuration was
+	// merged from several layers by Load
+	file    *Config
+	sources map[string]Layer
+	// entries are the package entries of the config files, before the
+	// environment and flag overrides applied by Load
+	entries map[string]PackageConfig
+	// base is the file content as loaded, used to find the changes to save
+	base *yaml.Node
+	// locations are the discovered package directories rules are matched against
+	locations map[string]packageLocation
 }
 
 // PackageConfig represents configuration for a specific package
 type PackageConfig struct {
-	ModulePath  string `yaml:"module_path"`
-	TagFormat   string `yaml:"tag_format"`
-	Repository  string `yaml:"repository,omitempty"`
-	UseDefault  bool   `yaml:"use_default"`
-	LastUpdated string `yaml:"last_updated,omitempty"`
+	ModulePath    string      `yaml:"module_path"`
+	TagFormat     string      `yaml:"tag_format,omitempty"`
+	Repository    string      `yaml:"repository,omitempty"`
+	UseDefault    bool        `yaml:"use_default"`
+	LastUpdated   string      `yaml:"last_updated,omitempty"`
+	Remotes       []string    `yaml:"remotes,omitempty"`
+	Gates         *GateConfig `yaml:"gates,omitempty"`
+	Bump          string      `yaml:"bump,omitempty"`
+	Hooks         *HookConfig `yaml:"hooks,omitempty"`
+	TagMessage    string      `yaml:"tag_message,omitempty"`
+	ReleaseBranch string      `yaml:"release_branch,omitempty"`
+	VersionScheme string      `yaml:"version_scheme,omitempty"`
 }
 
 // DefaultConfig represents default configuration
 type DefaultConfig struct {
-	TagFormat string `yaml:"tag_format"`
+	TagFormat     string      `yaml:"tag_format,omitempty"`
+	Remotes       []string    `yaml:"remotes,omitempty"`
+	Gates         *GateConfig `yaml:"gates,omitempty"`
+	Bump          string      `yaml:"bump,omitempty"`
+	Hooks         *HookConfig `yaml:"hooks,omitempty"`
+	TagMessage    string      `yaml:"tag_message,omitempty"`
+	ReleaseBranch string      `yaml:"release_branch,omitempty"`
+	VersionScheme string      `yaml:"version_scheme,omitempty"`
 }
 
-// DefaultTagFormat is the default tag format
-const DefaultTagFormat = "{package-name}/v{major}.{minor}.{patch}"
+// ReleaseRecord represents a release made by the tool, kept so it can be undone
+type ReleaseRecord struct {
+	ModulePath string   `yaml:"module_path,omitempty"`
+	Path       string   `yaml:"path,omitempty"`
+	Tag        string   `yaml:"tag,omitempty"`
+	Commit     string   `yaml:"commit,omitempty"`
+	Remotes    []string `yaml:"remotes,omitempty"`
+	CreatedAt  string   `yaml:"created_at"`
+	// Group is the version group of a group release, whose tags are the
+	// releases of its Members
+	Group   string          `yaml:"group,omitempty"`
+	Members []ReleaseRecord `yaml:"members,omitempty"`
+}
 
-// LoadConfig loads configuration from file
-func LoadConfig(configPath string) (*Config, error) {
-	config := &Config{
-		Packages: make(map[string]PackageConfig),
-		Defaults: DefaultConfig{
-			TagFormat: DefaultTagFormat,
-		},
+// Releases returns the single-package releases of the record: its members
+// for a group release, or the record itself
+func (r *ReleaseRecord) Releases() []ReleaseRecord {
+	if r.Group != "" {
+		return r.Members
 	}
+	return []ReleaseRecord{*r}
+}
 
-	// Check if config file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		// Create default config file
-		if err := SaveConfig(config, configPath); err != nil {
-			return nil, fmt.Errorf("failed to create default config: %w", err)
-		}
-		return config, nil
-	}
+// GateConfig represents the pre-tag quality gates for a package
+type GateConfig struct {
+	// Disabled turns off all gates for the package
+	Disabled bool `yaml:"disabled,omitempty"`
+	// SkipDefaults skips the built-in go build, vet and test gates
+	SkipDefaults bool `yaml:"skip_defaults,omitempty"`
+	// ModTidy enables the go mod tidy drift check (enabled when unset)
+	ModTidy *bool `yaml:"mod_tidy,omitempty"`
+	// Commands are additional shell commands run after the built-in gates
+	Commands []string `yaml:"commands,omitempty"`
+	// Timeout is the maximum duration of a single gate (e.g. "5m")
+	Timeout string `yaml:"timeout,omitempty"`
+}
 
-	// Read existing config file
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
+// DefaultTagFormat is the default tag format
+const DefaultTagFormat = "{package-name}/v{major}.{minor}.{patch}"
 
-	if err := yaml.Unmarshal(data, config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
-	}
+// DefaultTagMessage is the default annotation of created tags
+const DefaultTagMessage = "Release {tag} for {module}"
+
+// DefaultRemote is the remote tags are pushed to when none are configured
+const DefaultRemote = "origin"
 
-	// Ensure packages map is initialized
-	if config.Packages == nil {
-		config.Packages = make(map[string]PackageConfig)
+// DefaultGateTimeout is the default maximum duration of a single quality gate
+const DefaultGateTimeout = 10 * time.Minute
+
+// LoadConfig loads configuration from file. A missing file yields the
+// default configuration; it is only created when the configuration is saved.
+func LoadConfig(configPath string) (*Config, error) {
+	config, err := LoadFile(configPath)
+	if err != nil {
+		return nil, err
 	}
 
 	// Set default tag format if not specified
 	if config.Defaults.TagFormat == "" {
 		config.Defaults.TagFormat = DefaultTagFormat
 	}
+	config.applyDefaultTagFormat()
 
 	return config, nil
 }
 
-// SaveConfig saves configuration to file
+// SaveConfig saves configuration to file. The file is locked while it is
+// re-read, and only the changes made since the configuration was loaded are
+// applied to it, so concurrent runs don't overwrite each other's entries.
+// Callers holding the lock from Lock since loading serialize the whole cycle.
+// Comments and key order in the file are kept, and the file is replaced
+// atomically.
 func SaveConfig(config *Config, configPath string) error {
 	// Ensure directory exists
 	dir := filepath.Dir(configPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
+	configPath = resolveConfigPath(configPath)
 
-	data, err := yaml.Marshal(config)
+	// Only persist the layer loaded from the file, not merged values
+	if config.file != nil {
+		config.file.LastRelease = config.LastRelease
+		config = config.file
+	}
+
+	ours, err := encodeNode(config.saved())
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	unlock, err := lockConfig(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	doc, err := readDocument(configPath)
+	if err != nil {
+		return err
+	}
+	mergeChanges(doc.Content[0], config.base, ours)
+
+	data, err := encodeDocument(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := writeFileAtomic(configPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
+	// Later saves only apply changes made after this one
+	config.base = ours
 	return nil
 }
 
-// GetConfigPath returns the default config file path
+// saved returns the configuration as it is written to file
+func (c *Config) saved() *Config {
+	// Packages using the default format follow it rather than storing a copy
+	saved := *c
+	saved.SchemaVersion = CurrentSchemaVersion
+	saved.Packages = make(map[string]PackageConfig, len(c.Packages))
+	for modulePath, pkg := range c.Packages {
+		if pkg.UseDefault {
+			pkg.TagFormat = ""
+		}
+		saved.Packages[modulePath] = pkg
+	}
+	return &saved
+}
+
+// GetConfigPath returns the global config file path, which can be
+// overridden with the TAG_MANAGER_CONFIG environment variable
 func GetConfigPath() string {
+	if path := os.Getenv(EnvConfigPath); path != "" {
+		return path
+	}
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return ".tag-manager.yaml"
@@ -111,21 +214,198 @@ func GetDefaultConfig() *Config {
 	}
 }
 
-// GetPackageConfig returns configuration for a specific package
+// applyDefaultTagFormat sets the tag format of packages that use the default
+// or have no format of their own
+func (c *Config) applyDefaultTagFormat() {
+	for modulePath, pkg := range c.Packages {
+		if pkg.UseDefault || pkg.TagFormat == "" {
+			pkg.TagFormat = c.Defaults.TagFormat
+			pkg.UseDefault = true
+			c.Packages[modulePath] = pkg
+		}
+	}
+}
+
+// GetPackageConfig returns configuration for a specific package. The tag
+// format comes from the package entry, then the first matching rule, then
+// the defaults.
 func (c *Config) GetPackageConfig(modulePath string) PackageConfig {
+	pkg, exists := c.Packages[modulePath]
+	if !exists {
+		// Start from the default configuration
+		pkg = PackageConfig{
+			ModulePath: modulePath,
+			TagFormat:  c.Defaults.TagFormat,
+			UseDefault: true,
+		}
+	}
+
+	if pkg.UseDefault {
+		if rule, _ := c.MatchRule(modulePath); rule != nil && rule.TagFormat != "" {
+			pkg.TagFormat = rule.TagFormat
+		}
+	}
+	return pkg
+}
+
+// Inherited returns the settings a package gets from the first matching rule
+// and the defaults, ignoring the settings of its own entry
+func (c *Config) Inherited(modulePath string) PackageConfig {
+	view := *c
+	view.Packages = nil
 	if pkg, exists := c.Packages[modulePath]; exists {
-		return pkg
+		// Keep the repository rules are matched against
+		view.Packages = map[string]PackageConfig{modulePath: {ModulePath: modulePath, Repository: pkg.Repository, UseDefault: true}}
+	}
+
+	inherited := view.GetPackageConfig(modulePath)
+	if inherited.TagFormat == "" {
+		inherited.TagFormat = c.Defaults.TagFormat
 	}
+	inherited.Remotes = view.GetRemotes(modulePath)
+	inherited.Bump = view.GetBumpPolicy(modulePath)
+	hooks := view.GetHooks(modulePath)
+	inherited.Hooks = &hooks
+	inherited.TagMessage = view.GetTagMessage(modulePath)
+	inherited.VersionScheme = view.GetVersionScheme(modulePath)
+	return inherited
+}
 
-	// Return default configuration
-	return PackageConfig{
-		ModulePath: modulePath,
-		TagFormat:  c.Defaults.TagFormat,
-		UseDefault: true,
+// StoredPackageConfig returns the entry of a package as stored in the config
+// files, without the overrides of the environment and flags. Packages
+// without an entry get one following the defaults.
+func (c *Config) StoredPackageConfig(modulePath string) PackageConfig {
+	entries := c.entries
+	if entries == nil {
+		entries = c.Packages
+	}
+	if pkg, exists := entries[modulePath]; exists {
+		return pkg
 	}
+	return PackageConfig{ModulePath: modulePath, UseDefault: true}
 }
 
 // SetPackageConfig sets configuration for a specific package
 func (c *Config) SetPackageConfig(modulePath string, pkgConfig PackageConfig) {
 	c.Packages[modulePath] = pkgConfig
+	if c.entries != nil {
+		c.entries[modulePath] = pkgConfig
+	}
+	if c.file != nil {
+		c.file.Packages[modulePath] = pkgConfig
+		c.setSource("packages."+modulePath, LayerGlobal)
+	}
+}
+
+// TouchPackage records the time a package was last released. Only entries
+// stored in the saved layer are updated.
+func (c *Config) TouchPackage(modulePath, timestamp string) {
+	target := c
+	if c.file != nil {
+		target = c.file
+	}
+	if pkg, exists := target.Packages[modulePath]; exists {
+		pkg.LastUpdated = timestamp
+		target.Packages[modulePath] = pkg
+	}
+	if pkg, exists := c.Packages[modulePath]; exists {
+		pkg.LastUpdated = timestamp
+		c.Packages[modulePath] = pkg
+	}
+}
+
+// GetRemotes returns the remotes tags of a specific package are pushed to:
+// the package entry, then the first matching rule, then the defaults
+func (c *Config) GetRemotes(modulePath string) []string {
+	if pkg, exists := c.Packages[modulePath]; exists && len(pkg.Remotes) > 0 {
+		return pkg.Remotes
+	}
+	if rule, _ := c.MatchRule(modulePath); rule != nil && len(rule.Remotes) > 0 {
+		return rule.Remotes
+	}
+	if len(c.Defaults.Remotes) > 0 {
+		return c.Defaults.Remotes
+	}
+	return []string{DefaultRemote}
+}
+
+// GetTagMessage returns the annotation template of a package's tags: the
+// package entry, then the first matching rule, then the defaults
+func (c *Config) GetTagMessage(modulePath string) string {
+	if pkg, exists := c.Packages[modulePath]; exists && pkg.TagMessage != "" {
+		return pkg.TagMessage
+	}
+	if rule, _ := c.MatchRule(modulePath); rule != nil && rule.TagMessage != "" {
+		return rule.TagMessage
+	}
+	if c.Defaults.TagMessage != "" {
+		return c.Defaults.TagMessage
+	}
+	return DefaultTagMessage
+}
+
+// GetReleaseBranch returns the branch released commits of a package must be
+// reachable from: the package entry, then the first matching rule, then the
+// defaults. It is empty when none is configured.
+func (c *Config) GetReleaseBranch(modulePath string) string {
+	if pkg, exists := c.Packages[modulePath]; exists && pkg.ReleaseBranch != "" {
+		return pkg.ReleaseBranch
+	}
+	if rule, _ := c.MatchRule(modulePath); rule != nil && rule.ReleaseBranch != "" {
+		return rule.ReleaseBranch
+	}
+	return c.Defaults.ReleaseBranch
+}
+
+// GetVersionScheme returns how the versions of a package are numbered: the
+// package entry, then the first matching rule, then the defaults
+func (c *Config) GetVersionScheme(modulePath string) string {
+	if pkg, exists := c.Packages[modulePath]; exists && pkg.VersionScheme != "" {
+		return pkg.VersionScheme
+	}
+	if rule, _ := c.MatchRule(modulePath); rule != nil && rule.VersionScheme != "" {
+		return rule.VersionScheme
+	}
+	if c.Defaults.VersionScheme != "" {
+		return c.Defaults.VersionScheme
+	}
+	return tagutils.SchemeSemver
+}
+
+// GetCalverLayout returns the calendar version layout of a package, or an
+// empty string when it uses semver
+func (c *Config) GetCalverLayout(modulePath string) (string, error) {
+	return tagutils.SchemeLayout(c.GetVersionScheme(modulePath))
+}
+
+// GetGateConfig returns the quality gate configuration for a specific package,
+// falling back to the defaults when the package has none
+func (c *Config) GetGateConfig(modulePath string) GateConfig {
+	if pkg, exists := c.Packages[modulePath]; exists && pkg.Gates != nil {
+		return *pkg.Gates
+	}
+	if c.Defaults.Gates != nil {
+		return *c.Defaults.Gates
+	}
+	return GateConfig{}
+}
+
+// ModTidyEnabled reports whether the go mod tidy drift check should run
+func (g GateConfig) ModTidyEnabled() bool {
+	return g.ModTidy == nil || *g.ModTidy
+}
+
+// GateTimeout returns the per-gate timeout, falling back to DefaultGateTimeout
+func (g GateConfig) GateTimeout() (time.Duration, error) {
+	if g.Timeout == "" {
+		return DefaultGateTimeout, nil
+	}
+	timeout, err := time.ParseDuration(g.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid gate timeout %q: %w", g.Timeout, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid gate timeout %q: must be positive", g.Timeout)
+	}
+	return timeout, nil
 }
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// VersionGroup is a set of packages that always share a version number and
+// are released together
+type VersionGroup struct {
+	// Name identifies the group
+	Name string `yaml:"name"`
+	// Modules are module paths or patterns of the members, as in rule matches
+	Modules []string `yaml:"modules"`
+	// Regex treats the patterns as regular expressions instead of globs
+	Regex bool `yaml:"regex,omitempty"`
+}
+
+// Validate checks the name and patterns of the group
+func (g *VersionGroup) Validate() error {
+	if g.Name == "" {
+		return fmt.Errorf("group has no name")
+	}
+	if len(g.Modules) == 0 {
+		return fmt.Errorf("group %q has no modules", g.Name)
+	}
+	for _, pattern := range g.Modules {
+		if _, err := g.compile(pattern); err != nil {
+			return fmt.Errorf("group %q: invalid pattern %q: %w", g.Name, pattern, err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether a module is a member of the group
+func (g *VersionGroup) Matches(modulePath string) bool {
+	for _, pattern := range g.Modules {
+		if re, err := g.compile(pattern); err == nil && re.MatchString(modulePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// compile turns a pattern of the group into a regular expression
+func (g *VersionGroup) compile(pattern string) (*regexp.Regexp, error) {
+	if g.Regex {
+		return regexp.Compile(pattern)
+	}
+	return globRegexp(pattern)
+}
+
+// GroupOf returns the version group a module belongs to, or nil. A module
+// matching several groups belongs to the first.
+func (c *Config) GroupOf(modulePath string) *VersionGroup {
+	for i := range c.Groups {
+		if c.Groups[i].Matches(modulePath) {
+			return &c.Groups[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+)
+
+// DefaultsTarget addresses the defaults section in GetValue, SetValue and UnsetValue
+const DefaultsTarget = "defaults"
+
+// PackageKeys are the package settings that can be changed with SetValue
+var PackageKeys = []string{"tag_format", "remotes", "repository", "use_default", "bump", "tag_message", "release_branch", "version_scheme"}
+
+// DefaultKeys are the default settings that can be changed with SetValue
+var DefaultKeys = []string{"tag_format", "remotes", "bump", "tag_message", "release_branch", "version_scheme"}
+
+// GetValue returns a setting of a package, or of the defaults when target is
+// DefaultsTarget. List values are comma separated.
+func (c *Config) GetValue(target, key string) (string, error) {
+	if target == DefaultsTarget {
+		switch key {
+		case "tag_format":
+			return c.Defaults.TagFormat, nil
+		case "remotes":
+			return strings.Join(c.GetRemotes(""), ","), nil
+		case "bump":
+			return c.GetBumpPolicy(""), nil
+		case "tag_message":
+			return c.GetTagMessage(""), nil
+		case "release_branch":
+			return c.GetReleaseBranch(""), nil
+		case "version_scheme":
+			return c.GetVersionScheme(""), nil
+		}
+		return "", unknownKeyError(key, DefaultKeys)
+	}
+
+	pkg, exists := c.Packages[target]
+	if !exists {
+		return "", fmt.Errorf("package %s is not configured", target)
+	}
+
+	switch key {
+	case "tag_format":
+		return c.GetPackageConfig(target).TagFormat, nil
+	case "remotes":
+		return strings.Join(c.GetRemotes(target), ","), nil
+	case "repository":
+		return pkg.Repository, nil
+	case "use_default":
+		return strconv.FormatBool(pkg.UseDefault), nil
+	case "bump":
+		return c.GetBumpPolicy(target), nil
+	case "tag_message":
+		return c.GetTagMessage(target), nil
+	case "release_branch":
+		return c.GetReleaseBranch(target), nil
+	case "version_scheme":
+		return c.GetVersionScheme(target), nil
+	}
+	return "", unknownKeyError(key, PackageKeys)
+}
+
+// SetValue changes a setting of a package, or of the defaults when target is
+// DefaultsTarget. Packages without an entry are added.
+func (c *Config) SetValue(target, key, value string) error {
+	switch key {
+	case "tag_format":
+		if err := tagutils.ValidateTagFormat(value); err != nil {
+			return err
+		}
+	case "bump":
+		if err := ValidateBumpPolicy(value); err != nil {
+			return err
+		}
+	case "version_scheme":
+		if _, err := tagutils.SchemeLayout(value); err != nil {
+			return err
+		}
+	}
+
+	if target == DefaultsTarget {
+		switch key {
+		case "tag_format":
+			c.Defaults.TagFormat = value
+		case "remotes":
+			c.Defaults.Remotes = splitList(value)
+		case "bump":
+			c.Defaults.Bump = value
+		case "tag_message":
+			c.Defaults.TagMessage = value
+		case "release_branch":
+			c.Defaults.ReleaseBranch = value
+		case "version_scheme":
+			c.Defaults.VersionScheme = value
+		default:
+			return unknownKeyError(key, DefaultKeys)
+		}
+		return nil
+	}
+
+	pkg := c.GetPackageConfig(target)
+	switch key {
+	case "tag_format":
+		pkg.TagFormat = value
+		pkg.UseDefault = false
+	case "remotes":
+		pkg.Remotes = splitList(value)
+	case "repository":
+		pkg.Repository = value
+	case "use_default":
+		useDefault, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for use_default: %w", err)
+		}
+		pkg.UseDefault = useDefault
+		if useDefault {
+			pkg.TagFormat = c.Defaults.TagFormat
+		}
+	case "bump":
+		pkg.Bump = value
+	case "tag_message":
+		pkg.TagMessage = value
+	case "release_branch":
+		pkg.ReleaseBranch = value
+	case "version_scheme":
+		pkg.VersionScheme = value
+	default:
+		return unknownKeyError(key, PackageKeys)
+	}
+
+	c.SetPackageConfig(target, pkg)
+	return nil
+}
+
+// UnsetValue removes a setting of a package, or of the defaults when target
+// is DefaultsTarget. An empty key removes the whole package entry.
+func (c *Config) UnsetValue(target, key string) error {
+	if target == DefaultsTarget {
+		switch key {
+		case "tag_format":
+			c.Defaults.TagFormat = ""
+		case "remotes":
+			c.Defaults.Remotes = nil
+		case "bump":
+			c.Defaults.Bump = ""
+		case "tag_message":
+			c.Defaults.TagMessage = ""
+		case "release_branch":
+			c.Defaults.ReleaseBranch = ""
+		case "version_scheme":
+			c.Defaults.VersionScheme = ""
+		default:
+			return unknownKeyError(key, DefaultKeys)
+		}
+		return nil
+	}
+
+	pkg, exists := c.Packages[target]
+	if !exists {
+		return fmt.Errorf("package %s is not configured", target)
+	}
+
+	switch key {
+	case "":
+		delete(c.Packages, target)
+		if c.file != nil {
+			delete(c.file.Packages, target)
+		}
+		return nil
+	case "tag_format":
+		// Without its own format the package falls back to the default
+		pkg.TagFormat = c.Defaults.TagFormat
+		pkg.UseDefault = true
+	case "remotes":
+		pkg.Remotes = nil
+	case "repository":
+		pkg.Repository = ""
+	case "use_default":
+		pkg.UseDefault = false
+	case "bump":
+		pkg.Bump = ""
+	case "tag_message":
+		pkg.TagMessage = ""
+	case "release_branch":
+		pkg.ReleaseBranch = ""
+	case "version_scheme":
+		pkg.VersionScheme = ""
+	default:
+		return unknownKeyError(key, PackageKeys)
+	}
+
+	c.SetPackageConfig(target, pkg)
+	return nil
+}
+
+// unknownKeyError reports a key that isn't one of the supported keys
+func unknownKeyError(key string, supported []string) error {
+	keys := append([]string(nil), supported...)
+	sort.Strings(keys)
+	return fmt.Errorf("unknown key %q, expected one of: %s", key, strings.Join(keys, ", "))
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+	"gopkg.in/yaml.v3"
+)
+
+// Layer identifies where a configuration value came from
+type Layer string
+
+const (
+	// LayerBuiltin is the built-in default configuration
+	LayerBuiltin Layer = "built-in"
+	// LayerGlobal is the user's global configuration file
+	LayerGlobal Layer = "global"
+	// LayerRepo is the repository's .tag-manager.yaml
+	LayerRepo Layer = "repo"
+	// LayerEnv is a TAG_MANAGER_* environment variable
+	LayerEnv Layer = "env"
+	// LayerFlag is a command line flag
+	LayerFlag Layer = "flag"
+)
+
+// RepoConfigFile is the name of the repository configuration file
+const RepoConfigFile = ".tag-manager.yaml"
+
+// Environment variables overriding configuration values
+const (
+	EnvConfigPath = "TAG_MANAGER_CONFIG"
+	EnvTagFormat  = "TAG_MANAGER_TAG_FORMAT"
+	EnvRemotes    = "TAG_MANAGER_REMOTES"
+)
+
+// Overrides represents configuration values given on the command line
+type Overrides struct {
+	TagFormat string
+	Remotes   []string
+}
+
+// Load loads the effective configuration by merging, from lowest to highest
+// precedence, the built-in defaults, the global config file, the repository's
+// .tag-manager.yaml, TAG_MANAGER_* environment variables and overrides.
+// Rules of the repository file are tried before rules of the global file.
+// SaveConfig on the result only writes the global layer.
+func Load(overrides Overrides) (*Config, error) {
+	// Upgrade the global file in place; repository files are only migrated
+	// in memory since they are shared through version control, until a
+	// command saves to them
+	if _, err := MigrateFile(GetConfigPath(), false); err != nil {
+		return nil, fmt.Errorf("%s: %w", GetConfigPath(), err)
+	}
+
+	global, err := LoadFile(GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{
+		Packages:    make(map[string]PackageConfig),
+		Defaults:    DefaultConfig{TagFormat: DefaultTagFormat},
+		LastRelease: global.LastRelease,
+		file:        global,
+		sources:     map[string]Layer{"defaults.tag_format": LayerBuiltin, "defaults.remotes": LayerBuiltin},
+	}
+	config.merge(global, LayerGlobal)
+
+	if repoPath, ok := RepoConfigPath(); ok {
+		repo, err := LoadFile(repoPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load repository config %s: %w", repoPath, err)
+		}
+		config.merge(repo, LayerRepo)
+	}
+
+	for i := range config.Rules {
+		if err := config.Rules[i].Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s in %s config: %w", config.Rules[i].Describe(i), config.Rules[i].source, err)
+		}
+	}
+
+	// Entries without their own tag format follow the default
+	config.applyDefaultTagFormat()
+	config.entries = make(map[string]PackageConfig, len(config.Packages))
+	for modulePath, pkg := range config.Packages {
+		config.entries[modulePath] = pkg
+	}
+
+	if tagFormat := os.Getenv(EnvTagFormat); tagFormat != "" {
+		if err := tagutils.ValidateTagFormat(tagFormat); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvTagFormat, err)
+		}
+		config.overrideTagFormat(tagFormat, LayerEnv)
+	}
+	if remotes := splitList(os.Getenv(EnvRemotes)); len(remotes) > 0 {
+		config.overrideRemotes(remotes, LayerEnv)
+	}
+
+	if overrides.TagFormat != "" {
+		if err := tagutils.ValidateTagFormat(overrides.TagFormat); err != nil {
+			return nil, fmt.Errorf("invalid tag format override: %w", err)
+		}
+		config.overrideTagFormat(overrides.TagFormat, LayerFlag)
+	}
+	if len(overrides.Remotes) > 0 {
+		config.overrideRemotes(overrides.Remotes, LayerFlag)
+	}
+
+	return config, nil
+}
+
+// RepoConfigPath returns the path of the repository configuration file for
+// the current directory and whether it exists
+func RepoConfigPath() (string, bool) {
+	root, err := gitutils.RepoRoot("")
+	if err != nil {
+		return "", false
+	}
+
+	path := filepath.Join(root, RepoConfigFile)
+	// Don't treat the global file as a repository file when home is a repository
+	if path == GetConfigPath() {
+		return path, false
+	}
+	if _, err := os.Stat(path); err != nil {
+		return path, false
+	}
+	return path, true
+}
+
+// Source returns the layer a configuration value came from. Keys are
+// "defaults.tag_format", "defaults.remotes", "defaults.gates",
+// "packages.<module>" and "packages.<module>.<field>".
+func (c *Config) Source(key string) Layer {
+	if layer, exists := c.sources[key]; exists {
+		return layer
+	}
+	// Fields of a package entry come from the layer of the entry
+	if strings.HasPrefix(key, "packages.") {
+		for prefix := key; strings.Contains(prefix, "."); prefix = prefix[:strings.LastIndex(prefix, ".")] {
+			if layer, exists := c.sources[prefix]; exists {
+				return layer
+			}
+		}
+	}
+	return LayerBuiltin
+}
+
+// merge applies the values set in a configuration layer
+func (c *Config) merge(layer *Config, source Layer) {
+	if layer.Defaults.TagFormat != "" {
+		c.Defaults.TagFormat = layer.Defaults.TagFormat
+		c.setSource("defaults.tag_format", source)
+	}
+	if len(layer.Defaults.Remotes) > 0 {
+		c.Defaults.Remotes = layer.Defaults.Remotes
+		c.setSource("defaults.remotes", source)
+	}
+	if layer.Defaults.Gates != nil {
+		c.Defaults.Gates = layer.Defaults.Gates
+		c.setSource("defaults.gates", source)
+	}
+	if layer.Defaults.Bump != "" {
+		c.Defaults.Bump = layer.Defaults.Bump
+		c.setSource("defaults.bump", source)
+	}
+	if layer.Defaults.Hooks != nil {
+		c.Defaults.Hooks = layer.Defaults.Hooks
+		c.setSource("defaults.hooks", source)
+	}
+	if layer.Defaults.TagMessage != "" {
+		c.Defaults.TagMessage = layer.Defaults.TagMessage
+		c.setSource("defaults.tag_message", source)
+	}
+	if layer.Defaults.ReleaseBranch != "" {
+		c.Defaults.ReleaseBranch = layer.Defaults.ReleaseBranch
+		c.setSource("defaults.release_branch", source)
+	}
+	if layer.Defaults.VersionScheme != "" {
+		c.Defaults.VersionScheme = layer.Defaults.VersionScheme
+		c.setSource("defaults.version_scheme", source)
+	}
+
+	// Rules of higher layers are tried first
+	rules := make([]Rule, 0, len(layer.Rules)+len(c.Rules))
+	for _, rule := range layer.Rules {
+		rule.source = source
+		rules = append(rules, rule)
+	}
+	c.Rules = append(rules, c.Rules...)
+
+	// Groups replace groups of the same name from lower layers
+	groups := append([]VersionGroup(nil), layer.Groups...)
+	for _, group := range c.Groups {
+		if !hasGroup(layer.Groups, group.Name) {
+			groups = append(groups, group)
+		}
+	}
+	c.Groups = groups
+
+	// Package entries replace entries for the same module from lower layers
+	for modulePath, pkg := range layer.Packages {
+		c.Packages[modulePath] = pkg
+		c.setSource("packages."+modulePath, source)
+		delete(c.sources, "packages."+modulePath+".tag_format")
+		delete(c.sources, "packages."+modulePath+".remotes")
+	}
+}
+
+// hasGroup reports whether groups contains a group with the given name
+func hasGroup(groups []VersionGroup, name string) bool {
+	for _, group := range groups {
+		if group.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// overrideTagFormat sets the effective tag format of every package
+func (c *Config) overrideTagFormat(tagFormat string, source Layer) {
+	c.Defaults.TagFormat = tagFormat
+	c.setSource("defaults.tag_format", source)
+	for modulePath, pkg := range c.Packages {
+		pkg.TagFormat = tagFormat
+		c.Packages[modulePath] = pkg
+		c.setSource("packages."+modulePath+".tag_format", source)
+	}
+	for i := range c.Rules {
+		if c.Rules[i].TagFormat != "" {
+			c.Rules[i].TagFormat = tagFormat
+		}
+	}
+}
+
+// overrideRemotes sets the effective remotes of every package
+func (c *Config) overrideRemotes(remotes []string, source Layer) {
+	c.Defaults.Remotes = remotes
+	c.setSource("defaults.remotes", source)
+	for modulePath, pkg := range c.Packages {
+		pkg.Remotes = remotes
+		c.Packages[modulePath] = pkg
+		c.setSource("packages."+modulePath+".remotes", source)
+	}
+	for i := range c.Rules {
+		if len(c.Rules[i].Remotes) > 0 {
+			c.Rules[i].Remotes = remotes
+		}
+	}
+}
+
+// setSource records the layer a configuration value came from
+func (c *Config) setSource(key string, source Layer) {
+	if c.sources == nil {
+		c.sources = make(map[string]Layer)
+	}
+	c.sources[key] = source
+}
+
+// LoadFile reads a single configuration file without applying defaults,
+// migrating it in memory to the current schema. A missing file yields an
+// empty configuration.
+func LoadFile(path string) (*Config, error) {
+	config := &Config{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		config.Packages = make(map[string]PackageConfig)
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	migration, err := Migrate(data)
+	if err != nil {
+		return nil, err
+	}
+	data = migration.After
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if config.Packages == nil {
+		config.Packages = make(map[string]PackageConfig)
+	}
+	for modulePath, pkg := range config.Packages {
+		if pkg.ModulePath == "" {
+			pkg.ModulePath = modulePath
+			config.Packages[modulePath] = pkg
+		}
+	}
+
+	if config.base, err = encodeNode(config.saved()); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	return config, nil
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
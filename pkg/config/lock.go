@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// lockTimeout is how long to wait for another process to release the config lock
+const lockTimeout = 10 * time.Second
+
+// errLocked is returned by tryLock when another process holds the lock
+var errLocked = errors.New("lock is held by another process")
+
+// heldLock is a config lock taken by this process
+type heldLock struct {
+	file  *os.File
+	count int
+}
+
+// held holds the config locks of this process by lock file path, so a lock
+// taken with Lock can be taken again by SaveConfig without blocking
+var (
+	heldMu sync.Mutex
+	held   = make(map[string]*heldLock)
+)
+
+// Lock takes the lock guarding the config file at path for a whole
+// load-modify-save cycle and returns a function releasing it. Commands that
+// don't prompt hold it from loading the file until it is saved; interactive
+// commands would block other runs while waiting for input, so they rely on
+// SaveConfig merging their changes instead.
+func Lock(path string) (func(), error) {
+	return lockConfig(resolveConfigPath(path))
+}
+
+// lockConfig takes an exclusive advisory lock guarding the config file at
+// path. The lock lives in a separate file so it survives the file being
+// replaced on save. Locks are reentrant within the process. The returned
+// function releases it.
+func lockConfig(path string) (func(), error) {
+	lockPath := path + ".lock"
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	if lock, ok := held[lockPath]; ok {
+		lock.count++
+		return func() { releaseLock(lockPath) }, nil
+	}
+
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err := tryLock(file)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLocked) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock config file: %w", err)
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("config file %s is locked by another tag-manager process (lock file: %s)", path, lockPath)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	held[lockPath] = &heldLock{file: file, count: 1}
+	return func() { releaseLock(lockPath) }, nil
+}
+
+// releaseLock releases one hold of the lock at lockPath, unlocking the file
+// when no hold is left
+func releaseLock(lockPath string) {
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	lock, ok := held[lockPath]
+	if !ok {
+		return
+	}
+	if lock.count--; lock.count > 0 {
+		return
+	}
+	delete(held, lockPath)
+	unlock(lock.file)
+	lock.file.Close()
+}
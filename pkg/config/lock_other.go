@@ -0,0 +1,13 @@
+//go:build !unix && !windows
+
+package config
+
+import "os"
+
+// tryLock is a no-op on platforms without file locking
+func tryLock(file *os.File) error {
+	return nil
+}
+
+// unlock releases a lock taken by tryLock
+func unlock(file *os.File) {}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentSchemaVersion is the configuration schema written by this version
+// of the tool. Files without a schema_version are version 1.
+const CurrentSchemaVersion = 2
+
+// Migration upgrades a configuration document from one schema version to the next
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(root *yaml.Node) error
+}
+
+// migrations are applied in order to bring a file up to CurrentSchemaVersion.
+// Each entry upgrades From to From+1.
+var migrations = []Migration{
+	{
+		From:        1,
+		Description: "packages with use_default follow the default tag format instead of storing a copy of it",
+		Apply:       migrateUseDefault,
+	},
+}
+
+// MigrationResult describes the migration of a configuration document
+type MigrationResult struct {
+	From    int
+	To      int
+	Applied []Migration
+	Before  []byte
+	After   []byte
+}
+
+// Migrated reports whether any migration was applied
+func (r *MigrationResult) Migrated() bool {
+	return len(r.Applied) > 0
+}
+
+// Migrate upgrades a configuration document to CurrentSchemaVersion step by
+// step. Comments and key order are kept. Documents from a newer schema
+// version are rejected.
+func Migrate(data []byte) (*MigrationResult, error) {
+	result := &MigrationResult{Before: data, After: data, From: CurrentSchemaVersion, To: CurrentSchemaVersion}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	// Empty files have nothing to migrate
+	if len(doc.Content) == 0 {
+		return result, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse config file: expected a mapping at the top level")
+	}
+
+	version := 1
+	if node := mappingValue(root, "schema_version"); node != nil {
+		parsed, err := strconv.Atoi(node.Value)
+		if err != nil || parsed < 1 {
+			return nil, fmt.Errorf("invalid schema_version %q", node.Value)
+		}
+		version = parsed
+	}
+	if version > CurrentSchemaVersion {
+		return nil, fmt.Errorf("config schema version %d is newer than the supported version %d; "+
+			"upgrade tag-manager with 'go install github.com/gambitier/tag-manager@latest'", version, CurrentSchemaVersion)
+	}
+
+	result.From = version
+	for _, migration := range migrations {
+		if migration.From < version {
+			continue
+		}
+		if err := migration.Apply(root); err != nil {
+			return nil, fmt.Errorf("failed to migrate config from schema version %d: %w", migration.From, err)
+		}
+		result.Applied = append(result.Applied, migration)
+		version = migration.From + 1
+	}
+
+	if !result.Migrated() {
+		return result, nil
+	}
+
+	setSchemaVersion(root, version)
+	after, err := encodeDocument(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	result.To = version
+	result.After = after
+
+	return result, nil
+}
+
+// MigrateFile migrates the configuration file at path. Unless dryRun is set,
+// the original file is kept as a backup next to it and the migrated file is
+// written in its place.
+func MigrateFile(path string, dryRun bool) (*MigrationResult, error) {
+	path = resolveConfigPath(path)
+	result, err := migrateFile(path, true)
+	if err != nil || dryRun || !result.Migrated() {
+		return result, err
+	}
+
+	// Migrate again under the lock in case another process changed the file
+	unlock, err := lockConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return migrateFile(path, false)
+}
+
+// migrateFile migrates the file at path, writing the result unless dryRun is set
+func migrateFile(path string, dryRun bool) (*MigrationResult, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &MigrationResult{From: CurrentSchemaVersion, To: CurrentSchemaVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	result, err := Migrate(data)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || !result.Migrated() {
+		return result, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := backupFile(path, result.From, data); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(path, result.After, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write migrated config file: %w", err)
+	}
+
+	return result, nil
+}
+
+// backupFile keeps data, the content of the config file at path on schema
+// version, next to it before it is migrated
+func backupFile(path string, version int, data []byte) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := writeFileAtomic(BackupPath(path, version), data, perm); err != nil {
+		return fmt.Errorf("failed to back up config file: %w", err)
+	}
+	return nil
+}
+
+// BackupPath returns where the pre-migration copy of a config file is kept
+func BackupPath(path string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, version)
+}
+
+// migrateUseDefault drops the copied tag_format from packages that use the
+// default format, so they follow later changes to the default
+func migrateUseDefault(root *yaml.Node) error {
+	packages := mappingValue(root, "packages")
+	if packages == nil || packages.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 1; i < len(packages.Content); i += 2 {
+		pkg := packages.Content[i]
+		if pkg.Kind != yaml.MappingNode {
+			continue
+		}
+		useDefault := mappingValue(pkg, "use_default")
+		if useDefault != nil && useDefault.Value == "true" {
+			removeMappingKey(pkg, "tag_format")
+		}
+	}
+
+	return nil
+}
+
+// setSchemaVersion sets the schema_version key, adding it first if missing
+func setSchemaVersion(root *yaml.Node, version int) {
+	if node := mappingValue(root, "schema_version"); node != nil {
+		node.Value = strconv.Itoa(version)
+		node.Tag = "!!int"
+		return
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "schema_version"}
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+// mappingValue returns the value of key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// removeMappingKey removes key and its value from a mapping node
+func removeMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+)
+
+// Bump policies deciding the version type of a release
+const (
+	// BumpAsk lets the user select the version type
+	BumpAsk = "ask"
+	// BumpAPI uses the smallest version type allowed by the API check
+	BumpAPI = "api"
+)
+
+// BumpPolicies are the supported bump policies
+var BumpPolicies = []string{BumpAsk, BumpAPI, "patch", "minor", "major"}
+
+// Rule applies settings to every package it matches, so packages following
+// the same convention don't each need an entry
+type Rule struct {
+	// Name identifies the rule in explanations
+	Name string `yaml:"name,omitempty"`
+	// Match holds the patterns a package has to match, all of them if several are set
+	Match RuleMatch `yaml:"match"`
+	// Regex treats the patterns as regular expressions instead of globs
+	Regex         bool        `yaml:"regex,omitempty"`
+	TagFormat     string      `yaml:"tag_format,omitempty"`
+	Remotes       []string    `yaml:"remotes,omitempty"`
+	Bump          string      `yaml:"bump,omitempty"`
+	Hooks         *HookConfig `yaml:"hooks,omitempty"`
+	TagMessage    string      `yaml:"tag_message,omitempty"`
+	ReleaseBranch string      `yaml:"release_branch,omitempty"`
+	VersionScheme string      `yaml:"version_scheme,omitempty"`
+
+	// source is the layer the rule was loaded from
+	source Layer
+}
+
+// RuleMatch holds the patterns of a rule. Globs support * within a path
+// element, ** across elements and ? for a single character.
+type RuleMatch struct {
+	// Module is matched against the module path
+	Module string `yaml:"module,omitempty"`
+	// Repository is matched against the repository, e.g. github.com/owner/repo
+	Repository string `yaml:"repository,omitempty"`
+	// Dir is matched against the absolute package directory; ~/ is expanded
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// HookConfig represents shell commands run around tag creation
+type HookConfig struct {
+	// PreTag commands run before the tag is created; a failure aborts the release
+	PreTag []string `yaml:"pre_tag,omitempty"`
+	// PostTag commands run after the tag is pushed
+	PostTag []string `yaml:"post_tag,omitempty"`
+}
+
+// packageLocation is where a package was discovered
+type packageLocation struct {
+	dir        string
+	repository string
+	// resolved is set once the origin URL was looked up for repository
+	resolved bool
+}
+
+// Label returns the number and name of the rule
+func (r *Rule) Label(index int) string {
+	label := fmt.Sprintf("rule #%d", index+1)
+	if r.Name != "" {
+		label += fmt.Sprintf(" %q", r.Name)
+	}
+	return label
+}
+
+// Describe returns the label and patterns of the rule for messages
+func (r *Rule) Describe(index int) string {
+	var patterns []string
+	for _, pattern := range [][2]string{{"module", r.Match.Module}, {"repository", r.Match.Repository}, {"dir", r.Match.Dir}} {
+		if pattern[1] != "" {
+			patterns = append(patterns, pattern[0]+" "+pattern[1])
+		}
+	}
+
+	return fmt.Sprintf("%s (%s)", r.Label(index), strings.Join(patterns, ", "))
+}
+
+// Validate checks the patterns, tag format and bump policy of the rule
+func (r *Rule) Validate() error {
+	if r.Match.Module == "" && r.Match.Repository == "" && r.Match.Dir == "" {
+		return fmt.Errorf("no match pattern set")
+	}
+	for _, pattern := range []string{r.Match.Module, r.Match.Repository, r.Match.Dir} {
+		if pattern == "" {
+			continue
+		}
+		if _, err := r.compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	if r.TagFormat != "" {
+		if err := tagutils.ValidateTagFormat(r.TagFormat); err != nil {
+			return err
+		}
+	}
+	if _, err := tagutils.SchemeLayout(r.VersionScheme); err != nil {
+		return err
+	}
+	return ValidateBumpPolicy(r.Bump)
+}
+
+// Matches reports whether a package matches every pattern of the rule
+func (r *Rule) Matches(modulePath, dir, repository string) bool {
+	if r.Match.Module == "" && r.Match.Repository == "" && r.Match.Dir == "" {
+		return false
+	}
+
+	if r.Match.Dir != "" && dir != "" {
+		dir = filepath.ToSlash(filepath.Clean(dir))
+	}
+	for _, check := range [][2]string{{r.Match.Module, modulePath}, {r.Match.Repository, repository}, {r.Match.Dir, dir}} {
+		pattern, value := check[0], check[1]
+		if pattern == "" {
+			continue
+		}
+		re, err := r.compile(pattern)
+		if err != nil || value == "" || !re.MatchString(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// compile turns a pattern of the rule into a regular expression
+func (r *Rule) compile(pattern string) (*regexp.Regexp, error) {
+	if r.Regex {
+		return regexp.Compile(pattern)
+	}
+	return globRegexp(pattern)
+}
+
+// globRegexp converts a glob to an anchored regular expression
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(glob, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			glob = filepath.ToSlash(home) + glob[1:]
+		}
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// ValidateBumpPolicy checks that policy is empty or a supported bump policy
+func ValidateBumpPolicy(policy string) error {
+	if policy == "" {
+		return nil
+	}
+	for _, supported := range BumpPolicies {
+		if policy == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid bump policy %q, expected one of: %s", policy, strings.Join(BumpPolicies, ", "))
+}
+
+// SetPackageLocation records where a package was discovered, so rules
+// matching on directory or repository can apply to it
+func (c *Config) SetPackageLocation(modulePath, dir, repository string) {
+	if c.locations == nil {
+		c.locations = make(map[string]packageLocation)
+	}
+	c.locations[modulePath] = packageLocation{dir: dir, repository: repository}
+}
+
+// MatchRule returns the first rule matching a package and its index, or nil
+func (c *Config) MatchRule(modulePath string) (*Rule, int) {
+	if modulePath == "" {
+		return nil, -1
+	}
+
+	location := c.locations[modulePath]
+	repository := c.Packages[modulePath].Repository
+	if repository == "" {
+		repository = location.repository
+	}
+	if repository == "" && location.dir != "" && !location.resolved && c.hasRepositoryRule() {
+		// Fall back to the origin URL for repositories not hosted on GitHub
+		location.repository, _ = gitutils.Run(location.dir, "config", "--get", "remote.origin.url")
+		location.resolved = true
+		c.locations[modulePath] = location
+		repository = location.repository
+	}
+
+	for i := range c.Rules {
+		if c.Rules[i].Matches(modulePath, location.dir, repository) {
+			return &c.Rules[i], i
+		}
+	}
+	return nil, -1
+}
+
+// hasRepositoryRule reports whether any rule matches on repository
+func (c *Config) hasRepositoryRule() bool {
+	for _, rule := range c.Rules {
+		if rule.Match.Repository != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBumpPolicy returns how the version type of a package's releases is
+// chosen: the package entry, then the first matching rule, then the defaults
+func (c *Config) GetBumpPolicy(modulePath string) string {
+	if pkg, exists := c.Packages[modulePath]; exists && pkg.Bump != "" {
+		return pkg.Bump
+	}
+	if rule, _ := c.MatchRule(modulePath); rule != nil && rule.Bump != "" {
+		return rule.Bump
+	}
+	if c.Defaults.Bump != "" {
+		return c.Defaults.Bump
+	}
+	return BumpAsk
+}
+
+// GetHooks returns the hooks of a package: the package entry, then the first
+// matching rule, then the defaults
+func (c *Config) GetHooks(modulePath string) HookConfig {
+	if pkg, exists := c.Packages[modulePath]; exists && pkg.Hooks != nil {
+		return *pkg.Hooks
+	}
+	if rule, _ := c.MatchRule(modulePath); rule != nil && rule.Hooks != nil {
+		return *rule.Hooks
+	}
+	if c.Defaults.Hooks != nil {
+		return *c.Defaults.Hooks
+	}
+	return HookConfig{}
+}
+
+// Explanation describes how the effective settings of a package were resolved
+type Explanation struct {
+	ModulePath string
+	// Entry reports whether the package has its own entry
+	Entry bool
+	// Rule is the description of the first matching rule, if any
+	Rule   string
+	Values []ExplainedValue
+}
+
+// ExplainedValue is an effective setting and where it came from
+type ExplainedValue struct {
+	Key    string
+	Value  string
+	Origin string
+}
+
+// Explain resolves the settings of a package, recording for each whether it
+// came from the package entry, a rule or the defaults
+func (c *Config) Explain(modulePath string) Explanation {
+	explanation := Explanation{ModulePath: modulePath}
+	pkg, exists := c.Packages[modulePath]
+	explanation.Entry = exists
+	entryOrigin := fmt.Sprintf("package entry (%s)", c.Source("packages."+modulePath))
+
+	rule, index := c.MatchRule(modulePath)
+	var ruleOrigin string
+	if rule != nil {
+		explanation.Rule = rule.Describe(index)
+		ruleOrigin = fmt.Sprintf("%s (%s)", rule.Label(index), rule.source)
+	}
+	defaultsOrigin := func(key string) string {
+		return fmt.Sprintf("defaults (%s)", c.Source("defaults."+key))
+	}
+	// Environment and flag overrides apply to every package
+	overridden := func(key string) (string, bool) {
+		switch layer := c.Source("defaults." + key); layer {
+		case LayerEnv, LayerFlag:
+			return string(layer), true
+		}
+		return "", false
+	}
+
+	add := func(key, value, origin string) {
+		explanation.Values = append(explanation.Values, ExplainedValue{Key: key, Value: value, Origin: origin})
+	}
+
+	effective := c.GetPackageConfig(modulePath)
+	switch origin, isOverride := overridden("tag_format"); {
+	case isOverride:
+		add("tag_format", effective.TagFormat, origin)
+	case exists && !pkg.UseDefault:
+		add("tag_format", effective.TagFormat, entryOrigin)
+	case rule != nil && rule.TagFormat != "":
+		add("tag_format", effective.TagFormat, ruleOrigin)
+	default:
+		add("tag_format", effective.TagFormat, defaultsOrigin("tag_format"))
+	}
+
+	remotes := strings.Join(c.GetRemotes(modulePath), ", ")
+	switch origin, isOverride := overridden("remotes"); {
+	case isOverride:
+		add("remotes", remotes, origin)
+	case exists && len(pkg.Remotes) > 0:
+		add("remotes", remotes, entryOrigin)
+	case rule != nil && len(rule.Remotes) > 0:
+		add("remotes", remotes, ruleOrigin)
+	case len(c.Defaults.Remotes) > 0:
+		add("remotes", remotes, defaultsOrigin("remotes"))
+	default:
+		add("remotes", remotes, string(LayerBuiltin))
+	}
+
+	bump := c.GetBumpPolicy(modulePath)
+	switch {
+	case exists && pkg.Bump != "":
+		add("bump", bump, entryOrigin)
+	case rule != nil && rule.Bump != "":
+		add("bump", bump, ruleOrigin)
+	case c.Defaults.Bump != "":
+		add("bump", bump, defaultsOrigin("bump"))
+	default:
+		add("bump", bump, string(LayerBuiltin))
+	}
+
+	message := c.GetTagMessage(modulePath)
+	switch {
+	case exists && pkg.TagMessage != "":
+		add("tag_message", message, entryOrigin)
+	case rule != nil && rule.TagMessage != "":
+		add("tag_message", message, ruleOrigin)
+	case c.Defaults.TagMessage != "":
+		add("tag_message", message, defaultsOrigin("tag_message"))
+	default:
+		add("tag_message", message, string(LayerBuiltin))
+	}
+
+	branch := c.GetReleaseBranch(modulePath)
+	switch {
+	case exists && pkg.ReleaseBranch != "":
+		add("release_branch", branch, entryOrigin)
+	case rule != nil && rule.ReleaseBranch != "":
+		add("release_branch", branch, ruleOrigin)
+	case c.Defaults.ReleaseBranch != "":
+		add("release_branch", branch, defaultsOrigin("release_branch"))
+	default:
+		add("release_branch", branch, string(LayerBuiltin))
+	}
+
+	scheme := c.GetVersionScheme(modulePath)
+	switch {
+	case exists && pkg.VersionScheme != "":
+		add("version_scheme", scheme, entryOrigin)
+	case rule != nil && rule.VersionScheme != "":
+		add("version_scheme", scheme, ruleOrigin)
+	case c.Defaults.VersionScheme != "":
+		add("version_scheme", scheme, defaultsOrigin("version_scheme"))
+	default:
+		add("version_scheme", scheme, string(LayerBuiltin))
+	}
+
+	hooks := c.GetHooks(modulePath)
+	var hooksOrigin string
+	switch {
+	case exists && pkg.Hooks != nil:
+		hooksOrigin = entryOrigin
+	case rule != nil && rule.Hooks != nil:
+		hooksOrigin = ruleOrigin
+	case c.Defaults.Hooks != nil:
+		hooksOrigin = defaultsOrigin("hooks")
+	default:
+		hooksOrigin = string(LayerBuiltin)
+	}
+	add("hooks.pre_tag", strings.Join(hooks.PreTag, "; "), hooksOrigin)
+	add("hooks.post_tag", strings.Join(hooks.PostTag, "; "), hooksOrigin)
+
+	return explanation
+}
+
+// Layer returns the layer the rule was loaded from
+func (r *Rule) Layer() Layer {
+	return r.source
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+	"gopkg.in/yaml.v3"
+)
+
+// Issue represents a problem found while validating configuration
+type Issue struct {
+	File    string
+	Key     string
+	Message string
+}
+
+// String returns a human readable description of the issue
+func (i Issue) String() string {
+	var location []string
+	for _, part := range []string{i.File, i.Key} {
+		if part != "" {
+			location = append(location, part)
+		}
+	}
+	if len(location) == 0 {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(location, ": "), i.Message)
+}
+
+// ValidateFile checks a configuration file for syntax errors, unknown keys
+// and invalid tag formats. A missing file has no issues.
+func ValidateFile(path string) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return []Issue{{File: path, Message: fmt.Sprintf("invalid YAML: %v", err)}}, nil
+	}
+
+	var issues []Issue
+	if len(root.Content) > 0 {
+		issues = checkKeys(root.Content[0], reflect.TypeOf(Config{}), "", path)
+	}
+
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return append(issues, Issue{File: path, Message: fmt.Sprintf("invalid value: %v", err)}), nil
+	}
+
+	if config.SchemaVersion > CurrentSchemaVersion {
+		issues = append(issues, Issue{File: path, Key: "schema_version", Message: fmt.Sprintf("version %d is newer than the supported version %d", config.SchemaVersion, CurrentSchemaVersion)})
+	}
+
+	if config.Defaults.TagFormat != "" {
+		if err := tagutils.ValidateTagFormat(config.Defaults.TagFormat); err != nil {
+			issues = append(issues, Issue{File: path, Key: "defaults.tag_format", Message: err.Error()})
+		}
+	}
+	if err := ValidateBumpPolicy(config.Defaults.Bump); err != nil {
+		issues = append(issues, Issue{File: path, Key: "defaults.bump", Message: err.Error()})
+	}
+	if _, err := tagutils.SchemeLayout(config.Defaults.VersionScheme); err != nil {
+		issues = append(issues, Issue{File: path, Key: "defaults.version_scheme", Message: err.Error()})
+	}
+	for _, modulePath := range sortedModulePaths(config.Packages) {
+		pkg := config.Packages[modulePath]
+		if pkg.TagFormat != "" {
+			if err := tagutils.ValidateTagFormat(pkg.TagFormat); err != nil {
+				issues = append(issues, Issue{File: path, Key: "packages." + modulePath + ".tag_format", Message: err.Error()})
+			}
+		}
+		if err := ValidateBumpPolicy(pkg.Bump); err != nil {
+			issues = append(issues, Issue{File: path, Key: "packages." + modulePath + ".bump", Message: err.Error()})
+		}
+		if _, err := tagutils.SchemeLayout(pkg.VersionScheme); err != nil {
+			issues = append(issues, Issue{File: path, Key: "packages." + modulePath + ".version_scheme", Message: err.Error()})
+		}
+	}
+	for i := range config.Rules {
+		if err := config.Rules[i].Validate(); err != nil {
+			issues = append(issues, Issue{File: path, Key: fmt.Sprintf("rules[%d]", i), Message: err.Error()})
+		}
+	}
+	for i := range config.Groups {
+		key := fmt.Sprintf("groups[%d]", i)
+		if err := config.Groups[i].Validate(); err != nil {
+			issues = append(issues, Issue{File: path, Key: key, Message: err.Error()})
+		} else if hasGroup(config.Groups[:i], config.Groups[i].Name) {
+			issues = append(issues, Issue{File: path, Key: key, Message: fmt.Sprintf("group %q is defined more than once", config.Groups[i].Name)})
+		}
+	}
+
+	return issues, nil
+}
+
+// checkKeys reports mapping keys that don't correspond to a field of t
+func checkKeys(node *yaml.Node, t reflect.Type, path, file string) []Issue {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var issues []Issue
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			fieldType, known := fields[key]
+			if !known {
+				issues = append(issues, Issue{File: file, Key: joinKey(path, key), Message: "unknown key"})
+				continue
+			}
+			issues = append(issues, checkKeys(node.Content[i+1], fieldType, joinKey(path, key), file)...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			issues = append(issues, checkKeys(node.Content[i+1], t.Elem(), joinKey(path, node.Content[i].Value), file)...)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			issues = append(issues, checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), file)...)
+		}
+	}
+
+	return issues
+}
+
+// yamlFields maps the YAML keys of a struct to their field types
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// joinKey joins a parent key path and a child key
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// sortedModulePaths returns the module paths of packages in sorted order
+func sortedModulePaths(packages map[string]PackageConfig) []string {
+	modulePaths := make([]string, 0, len(packages))
+	for modulePath := range packages {
+		modulePaths = append(modulePaths, modulePath)
+	}
+	sort.Strings(modulePaths)
+	return modulePaths
+}
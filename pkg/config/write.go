@@ -0,0 +1,187 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeFileAtomic replaces the file at path by writing a temporary file in
+// the same directory and renaming it over the original. The permissions of
+// an existing file are kept; new files get perm.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Clean up the temporary file unless it was renamed into place
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// resolveConfigPath follows symlinks so saving replaces the linked file
+// rather than the link
+func resolveConfigPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// readDocument reads and migrates the config file at path as a YAML node,
+// keeping comments and key order. A file on an older schema is backed up
+// first since saving the document rewrites it. A missing or empty file
+// yields an empty mapping.
+func readDocument(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if len(data) > 0 {
+		migration, err := Migrate(data)
+		if err != nil {
+			return nil, err
+		}
+		if migration.Migrated() {
+			if err := backupFile(path, migration.From, data); err != nil {
+				return nil, err
+			}
+		}
+		if err := yaml.Unmarshal(migration.After, doc); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+
+	if len(doc.Content) == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse config file: expected a mapping at the top level")
+	}
+	return doc, nil
+}
+
+// encodeNode converts a value to a YAML node
+func encodeNode(value any) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// mergeChanges applies the changes between base and ours to the mapping
+// node current, so values changed by another process since base was read
+// are kept. Keys changed in both are set to ours. Nodes of current that are
+// kept or updated in place retain their comments and order.
+func mergeChanges(current, base, ours *yaml.Node) {
+	for i := 0; i+1 < len(ours.Content); i += 2 {
+		key, value := ours.Content[i], ours.Content[i+1]
+
+		var baseValue *yaml.Node
+		if base != nil {
+			baseValue = mappingValue(base, key.Value)
+		}
+		if baseValue != nil && nodesEqual(baseValue, value) {
+			continue
+		}
+
+		currentValue := mappingValue(current, key.Value)
+		switch {
+		case currentValue == nil:
+			current.Content = append(current.Content, key, value)
+		case currentValue.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeChanges(currentValue, baseValue, value)
+		default:
+			setMappingValue(current, key.Value, value)
+		}
+	}
+
+	// Keys removed since base are removed unless they only exist in current
+	if base == nil {
+		return
+	}
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		if key := base.Content[i].Value; mappingValue(ours, key) == nil {
+			removeMappingKey(current, key)
+		}
+	}
+}
+
+// setMappingValue replaces the value of key in a mapping node, keeping the
+// comments attached to the old value
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			old := node.Content[i+1]
+			replacement := *value
+			if replacement.LineComment == "" {
+				replacement.LineComment = old.LineComment
+			}
+			if replacement.HeadComment == "" {
+				replacement.HeadComment = old.HeadComment
+			}
+			node.Content[i+1] = &replacement
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// nodesEqual reports whether two nodes hold the same value, ignoring
+// comments and style
+func nodesEqual(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || len(a.Content) != len(b.Content) {
+		return false
+	}
+	if a.Kind == yaml.ScalarNode && (a.Value != b.Value || a.ShortTag() != b.ShortTag()) {
+		return false
+	}
+	for i := range a.Content {
+		if !nodesEqual(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeDocument renders a YAML document in the format the tool writes
+func encodeDocument(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(4)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,93 @@
+package discovery
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+)
+
+// Details holds package information that needs extra git commands to
+// compute, loaded on demand with LoadDetails
+type Details struct {
+	// RepoRoot is the root of the git repository containing the package
+	RepoRoot string
+	// Dir is the package directory relative to RepoRoot
+	Dir string
+	// TagDate is when the latest tag was created
+	TagDate time.Time
+	// UnreleasedCommits counts commits touching the package since the latest
+	// tag, or since the start of history when it has no tag
+	UnreleasedCommits int
+}
+
+// detailsWorkers bounds the git commands run concurrently by LoadDetails
+const detailsWorkers = 8
+
+// LoadDetails computes the details of packages that don't have them yet
+func LoadDetails(packages []Package) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, detailsWorkers)
+	for i := range packages {
+		if packages[i].Details != nil {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(pkg *Package) {
+			defer wg.Done()
+			pkg.Details = loadDetails(*pkg, nestedModules(*pkg, packages))
+			<-sem
+		}(&packages[i])
+	}
+	wg.Wait()
+}
+
+// nestedModules returns the directories of packages inside pkg's directory,
+// relative to it
+func nestedModules(pkg Package, packages []Package) []string {
+	var nested []string
+	for _, other := range packages {
+		if rel, err := filepath.Rel(pkg.Path, other.Path); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			nested = append(nested, filepath.ToSlash(rel))
+		}
+	}
+	return nested
+}
+
+// loadDetails computes the details of a single package. Commits touching
+// only the nested modules don't count as unreleased changes.
+func loadDetails(pkg Package, nested []string) *Details {
+	details := &Details{UnreleasedCommits: -1}
+
+	if root, err := gitutils.RepoRoot(pkg.Path); err == nil {
+		details.RepoRoot = root
+		if resolved, err := filepath.EvalSymlinks(pkg.Path); err == nil {
+			if dir, err := filepath.Rel(root, resolved); err == nil {
+				details.Dir = filepath.ToSlash(dir)
+			}
+		}
+	}
+
+	revisions := "HEAD"
+	if pkg.LatestTag != "" {
+		if tags, err := gitutils.ListTags(pkg.Path, pkg.LatestTag); err == nil && len(tags) > 0 {
+			details.TagDate = tags[0].Date
+		}
+		revisions = pkg.LatestTag + "..HEAD"
+	}
+	args := []string{"rev-list", "--count", revisions, "--", "."}
+	for _, dir := range nested {
+		args = append(args, ":(exclude)"+dir)
+	}
+	if output, err := gitutils.Run(pkg.Path, args...); err == nil {
+		if count, err := strconv.Atoi(output); err == nil {
+			details.UnreleasedCommits = count
+		}
+	}
+
+	return details
+}
@@ -9,6 +9,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
 )
 
 // Package represents a discovered Go package
@@ -18,7 +21,17 @@ type Package struct {
 	Path        string
 	PackageName string
 	GitHubRepo  string
-	LatestTag   string
+	// LatestTag is the latest stable release tag, set by LoadTags
+	LatestTag string
+	// LatestVersion is the version of LatestTag, set by LoadTags
+	LatestVersion string
+	// LatestPrerelease is the latest pre-release tag newer than LatestTag,
+	// set by LoadTags
+	LatestPrerelease string
+	// Group is the version group of the package, set from configuration
+	Group string
+	// Details is nil until loaded with LoadDetails
+	Details *Details
 }
 
 // DiscoverPackages scans for Go modules and returns discovered packages
@@ -72,7 +85,7 @@ func scanDirectory(rootPath string) ([]Package, error) {
 			pkg, err := parseGoMod(path)
 			if err != nil {
 				// Log error but continue scanning
-				fmt.Printf("Warning: failed to parse %s: %v\n", path, err)
+				fmt.Fprintf(os.Stderr, "Warning: failed to parse %s: %v\n", path, err)
 				return nil
 			}
 
@@ -121,16 +134,12 @@ func parseGoMod(filePath string) (Package, error) {
 	// Get GitHub repository from git config
 	githubRepo := getGitHubRepo(filepath.Dir(filePath))
 
-	// Get latest tag from git
-	latestTag := getLatestTag(filepath.Dir(filePath))
-
 	return Package{
 		ModulePath:  modulePath,
 		GoVersion:   goVersion,
 		Path:        filepath.Dir(filePath),
 		PackageName: packageName,
 		GitHubRepo:  githubRepo,
-		LatestTag:   latestTag,
 	}, nil
 }
 
@@ -188,42 +197,66 @@ func getGitHubRepo(path string) string {
 	return ""
 }
 
-// getLatestTag gets the latest tag for a specific package from git
-func getLatestTag(path string) string {
-	// Get the directory name to use as package name
-	packageName := filepath.Base(path)
+// LoadTags sets the latest release and pre-release of the package from the
+// tags format produces for it, rendering versions with the calendar layout
+// when one is given. Tags of other packages or in other formats, and
+// snapshots, are ignored.
+func (p *Package) LoadTags(format, layout string) {
+	packageName := tagutils.ExtractPackageNameFromModule(p.ModulePath)
+	tags, err := gitutils.ListTags(p.Path, tagutils.FormatGlob(format, packageName))
+	if err != nil {
+		return
+	}
 
-	// Try to get package-specific tags first (format: package-name/v*)
-	cmd := exec.Command("git", "tag", "--sort=-version:refname", "--list", fmt.Sprintf("%s/v*", packageName))
-	cmd.Dir = path
-	output, err := cmd.Output()
-	if err == nil {
-		tags := strings.Split(strings.TrimSpace(string(output)), "\n")
-		if len(tags) > 0 && tags[0] != "" {
-			return tags[0]
+	var stableInfo, prereleaseInfo *tagutils.TagInfo
+	for _, tag := range tags {
+		info, ok := tagutils.MatchTag(format, packageName, tag.Name)
+		switch {
+		case !ok:
+		case info.IsPrerelease():
+			if prereleaseInfo == nil || tagutils.CompareVersions(info, prereleaseInfo) > 0 {
+				p.LatestPrerelease, prereleaseInfo = tag.Name, info
+			}
+		case stableInfo == nil || tagutils.CompareVersions(info, stableInfo) > 0:
+			p.LatestTag, stableInfo = tag.Name, info
 		}
 	}
 
-	// If no package-specific tags found, try alternative patterns
-	patterns := []string{
-		fmt.Sprintf("%s-*", packageName),  // package-name-v1.2.3
-		fmt.Sprintf("v*%s*", packageName), // v1.2.3-package-name
-		"v*",                              // any v* tags
+	if stableInfo != nil && prereleaseInfo != nil && tagutils.CompareVersions(prereleaseInfo, stableInfo) < 0 {
+		p.LatestPrerelease = ""
 	}
+	if stableInfo != nil {
+		if layout != "" {
+			stableInfo.SetLayout(layout)
+		}
+		p.LatestVersion = stableInfo.Version
+	}
+}
 
-	for _, pattern := range patterns {
-		cmd := exec.Command("git", "tag", "--sort=-version:refname", "--list", pattern)
-		cmd.Dir = path
-		output, err := cmd.Output()
-		if err == nil {
-			tags := strings.Split(strings.TrimSpace(string(output)), "\n")
-			if len(tags) > 0 && tags[0] != "" {
-				return tags[0]
-			}
+// FindPackage finds a package by module path or package name
+func FindPackage(packages []Package, query string) (*Package, error) {
+	var matches []*Package
+	for i := range packages {
+		if packages[i].ModulePath == query {
+			return &packages[i], nil
+		}
+		if packages[i].PackageName == query {
+			matches = append(matches, &packages[i])
 		}
 	}
 
-	return ""
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("package %s not found", query)
+	case 1:
+		return matches[0], nil
+	default:
+		modulePaths := make([]string, len(matches))
+		for i, pkg := range matches {
+			modulePaths[i] = pkg.ModulePath
+		}
+		return nil, fmt.Errorf("package name %s is ambiguous, use the module path: %s", query, strings.Join(modulePaths, ", "))
+	}
 }
 
 // GetDefaultSearchPaths returns default search paths for package discovery
@@ -0,0 +1,353 @@
+package discovery
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Field is a package attribute that can be shown, filtered and sorted on
+type Field struct {
+	Name   string
+	Header string
+	// Details marks fields that need LoadDetails
+	Details bool
+	Value   func(pkg *Package) string
+}
+
+// Fields are the package fields available to list columns, filters and sorting
+var Fields = []Field{
+	{Name: "name", Header: "Package", Value: func(pkg *Package) string { return pkg.PackageName }},
+	{Name: "module", Header: "Module", Value: func(pkg *Package) string { return pkg.ModulePath }},
+	{Name: "path", Header: "Path", Value: func(pkg *Package) string { return pkg.Path }},
+	{Name: "go", Header: "Go Version", Value: func(pkg *Package) string { return pkg.GoVersion }},
+	{Name: "repo", Header: "GitHub", Value: func(pkg *Package) string { return pkg.GitHubRepo }},
+	{Name: "tag", Header: "Latest Tag", Value: func(pkg *Package) string { return pkg.LatestTag }},
+	{Name: "prerelease", Header: "Prerelease", Value: func(pkg *Package) string { return pkg.LatestPrerelease }},
+	{Name: "version", Header: "Version", Value: func(pkg *Package) string { return pkg.LatestVersion }},
+	{Name: "group", Header: "Group", Value: func(pkg *Package) string { return pkg.Group }},
+	{Name: "repo_root", Header: "Repository Root", Details: true, Value: func(pkg *Package) string { return pkg.Details.RepoRoot }},
+	{Name: "dir", Header: "Dir", Details: true, Value: func(pkg *Package) string { return pkg.Details.Dir }},
+	{Name: "tag_date", Header: "Tag Date", Details: true, Value: func(pkg *Package) string {
+		if pkg.Details.TagDate.IsZero() {
+			return ""
+		}
+		return pkg.Details.TagDate.Format("2006-01-02")
+	}},
+	{Name: "unreleased", Header: "Unreleased", Details: true, Value: func(pkg *Package) string {
+		if pkg.Details.UnreleasedCommits < 0 {
+			return ""
+		}
+		return strconv.Itoa(pkg.Details.UnreleasedCommits)
+	}},
+}
+
+// LookupField returns the field with the given name
+func LookupField(name string) (Field, error) {
+	for _, field := range Fields {
+		if field.Name == name {
+			return field, nil
+		}
+	}
+	return Field{}, fmt.Errorf("unknown field %q, expected one of: %s", name, strings.Join(FieldNames(), ", "))
+}
+
+// FieldNames returns the names of all fields
+func FieldNames() []string {
+	names := make([]string, len(Fields))
+	for i, field := range Fields {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// Filter selects packages matching all of its conditions
+type Filter struct {
+	conditions []condition
+}
+
+// condition is a single filter term
+type condition struct {
+	field Field
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+// filterOperators are the comparison operators; at the same position two
+// character operators win since they are tried first
+var filterOperators = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+// ParseFilter parses a filter expression: terms separated by commas or
+// spaces that must all match. A term is "field<op>value" with op one of
+// = and != (glob), ~ (regular expression) or >, >=, <, <= (versions, dates
+// and counts), or one of has-tag, changed and their negations with a
+// leading !. Any other bare word is a glob on the package name, or on the
+// module path when it contains a slash.
+func ParseFilter(expr string) (*Filter, error) {
+	filter := &Filter{}
+	terms := strings.FieldsFunc(expr, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	for _, term := range terms {
+		cond, err := parseCondition(term)
+		if err != nil {
+			return nil, err
+		}
+		filter.conditions = append(filter.conditions, cond)
+	}
+	return filter, nil
+}
+
+// parseCondition parses a single filter term
+func parseCondition(term string) (condition, error) {
+	switch term {
+	case "has-tag":
+		return condition{field: mustField("tag"), op: "!=", value: ""}, nil
+	case "!has-tag":
+		return condition{field: mustField("tag"), op: "=", value: ""}, nil
+	case "changed", "changed-since-tag":
+		return condition{field: mustField("unreleased"), op: ">", value: "0"}, nil
+	case "!changed", "!changed-since-tag":
+		return condition{field: mustField("unreleased"), op: "=", value: "0"}, nil
+	}
+
+	// The first operator in the term separates field and value
+	op, index := "", -1
+	for _, candidate := range filterOperators {
+		if i := strings.Index(term, candidate); i > 0 && (index < 0 || i < index) {
+			op, index = candidate, i
+		}
+	}
+	if index > 0 {
+		field, err := LookupField(term[:index])
+		if err != nil {
+			return condition{}, fmt.Errorf("invalid filter %q: %w", term, err)
+		}
+		cond := condition{field: field, op: op, value: term[index+len(op):]}
+		if op == "~" {
+			if cond.re, err = regexp.Compile(cond.value); err != nil {
+				return condition{}, fmt.Errorf("invalid filter %q: %w", term, err)
+			}
+		}
+		if (op == "=" || op == "!=") && cond.value != "" {
+			if _, err := path.Match(cond.value, ""); err != nil {
+				return condition{}, fmt.Errorf("invalid filter %q: %w", term, err)
+			}
+		}
+		return cond, nil
+	}
+
+	// Bare words match the package name, or the module path
+	field := mustField("name")
+	if strings.Contains(term, "/") {
+		field = mustField("module")
+	}
+	if _, err := path.Match(term, ""); err != nil {
+		return condition{}, fmt.Errorf("invalid filter %q: %w", term, err)
+	}
+	return condition{field: field, op: "=", value: term}, nil
+}
+
+// mustField returns a field known to exist
+func mustField(name string) Field {
+	field, err := LookupField(name)
+	if err != nil {
+		panic(err)
+	}
+	return field
+}
+
+// Empty reports whether the filter has no conditions and matches everything
+func (f *Filter) Empty() bool {
+	return f == nil || len(f.conditions) == 0
+}
+
+// NeedsDetails reports whether matching needs LoadDetails
+func (f *Filter) NeedsDetails() bool {
+	if f == nil {
+		return false
+	}
+	for _, cond := range f.conditions {
+		if cond.field.Details {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the packages matching the filter, loading details first if
+// the filter needs them
+func (f *Filter) Apply(packages []Package) []Package {
+	if f.Empty() {
+		return packages
+	}
+	if f.NeedsDetails() {
+		LoadDetails(packages)
+	}
+
+	var matched []Package
+	for i := range packages {
+		if f.Match(&packages[i]) {
+			matched = append(matched, packages[i])
+		}
+	}
+	return matched
+}
+
+// Match reports whether a package matches every condition of the filter.
+// Details must be loaded if the filter needs them.
+func (f *Filter) Match(pkg *Package) bool {
+	if f == nil {
+		return true
+	}
+	for _, cond := range f.conditions {
+		if !cond.match(pkg) {
+			return false
+		}
+	}
+	return true
+}
+
+// match reports whether a package satisfies the condition
+func (c condition) match(pkg *Package) bool {
+	if c.field.Details && pkg.Details == nil {
+		return false
+	}
+	value := c.field.Value(pkg)
+
+	switch c.op {
+	case "=", "!=":
+		var matched bool
+		if c.value == "" {
+			matched = value == ""
+		} else {
+			matched, _ = path.Match(c.value, value)
+		}
+		return matched == (c.op == "=")
+	case "~":
+		return c.re.MatchString(value)
+	}
+
+	// Ordered comparisons never match missing values
+	if value == "" {
+		return false
+	}
+	cmp := compareFieldValues(c.field.Name, value, c.value)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// compareFieldValues compares two values of a field, numerically for
+// versions and counts and as text otherwise
+func compareFieldValues(field, a, b string) int {
+	switch field {
+	case "go", "version":
+		return compareDotted(a, b)
+	case "unreleased":
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x - y
+	}
+	return strings.Compare(a, b)
+}
+
+// compareDotted compares dotted version numbers such as 1.21 or v1.2.3,
+// ignoring pre-release suffixes. Missing components count as zero.
+func compareDotted(a, b string) int {
+	parse := func(version string) []int {
+		version = strings.TrimPrefix(version, "v")
+		if i := strings.IndexAny(version, "-+"); i >= 0 {
+			version = version[:i]
+		}
+		var parts []int
+		for _, part := range strings.Split(version, ".") {
+			n, _ := strconv.Atoi(part)
+			parts = append(parts, n)
+		}
+		return parts
+	}
+
+	x, y := parse(a), parse(b)
+	for i := 0; i < len(x) || i < len(y); i++ {
+		var p, q int
+		if i < len(x) {
+			p = x[i]
+		}
+		if i < len(y) {
+			q = y[i]
+		}
+		if p != q {
+			return p - q
+		}
+	}
+	return 0
+}
+
+// sortAliases maps additional sort key names to fields
+var sortAliases = map[string]string{
+	"date":         "tag_date",
+	"last-release": "tag_date",
+}
+
+// SortNeedsDetails reports whether sorting by key needs LoadDetails
+func SortNeedsDetails(key string) bool {
+	field, err := sortField(key)
+	return err == nil && field.Details
+}
+
+// SortPackages sorts packages by a field, in descending order when key starts
+// with "-". Packages without a value sort last. Details are loaded if the
+// field needs them.
+func SortPackages(packages []Package, key string) error {
+	descending := strings.HasPrefix(key, "-")
+	field, err := sortField(key)
+	if err != nil {
+		return err
+	}
+	if field.Details {
+		LoadDetails(packages)
+	}
+
+	sort.SliceStable(packages, func(i, j int) bool {
+		a, b := field.Value(&packages[i]), field.Value(&packages[j])
+		if a == "" || b == "" {
+			return a != "" && b == ""
+		}
+
+		var cmp int
+		if field.Name == "tag_date" {
+			cmp = packages[i].Details.TagDate.Compare(packages[j].Details.TagDate)
+		} else {
+			cmp = compareFieldValues(field.Name, a, b)
+		}
+		if descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+	return nil
+}
+
+// sortField resolves a sort key to a field
+func sortField(key string) (Field, error) {
+	name := strings.TrimPrefix(key, "-")
+	if alias, exists := sortAliases[name]; exists {
+		name = alias
+	}
+	field, err := LookupField(name)
+	if err != nil {
+		return Field{}, fmt.Errorf("invalid sort key %q: %w", key, err)
+	}
+	return field, nil
+}
@@ -0,0 +1,73 @@
+package discovery
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+)
+
+// Repository is a git repository and the discovered packages inside it
+type Repository struct {
+	// Root is the repository root, empty for packages outside a repository
+	Root string
+	// Remote is the URL of the origin remote, or of the first remote
+	Remote string
+	// Branch is the checked out branch, empty when HEAD is detached
+	Branch   string
+	Packages []Package
+}
+
+// GroupByRepository groups packages by the git repository containing them,
+// loading their details first. Repositories are sorted by root, with
+// packages outside any repository last.
+func GroupByRepository(packages []Package) []Repository {
+	LoadDetails(packages)
+
+	byRoot := make(map[string]*Repository)
+	var roots []string
+	for _, pkg := range packages {
+		root := pkg.Details.RepoRoot
+		repo, exists := byRoot[root]
+		if !exists {
+			repo = &Repository{Root: root}
+			if root != "" {
+				repo.Remote, repo.Branch = repositoryInfo(root)
+			}
+			byRoot[root] = repo
+			roots = append(roots, root)
+		}
+		repo.Packages = append(repo.Packages, pkg)
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		if roots[i] == "" || roots[j] == "" {
+			return roots[j] == ""
+		}
+		return roots[i] < roots[j]
+	})
+
+	repositories := make([]Repository, 0, len(roots))
+	for _, root := range roots {
+		repositories = append(repositories, *byRoot[root])
+	}
+	return repositories
+}
+
+// repositoryInfo returns the remote URL and current branch of a repository
+func repositoryInfo(root string) (string, string) {
+	remote, err := gitutils.Run(root, "config", "--get", "remote.origin.url")
+	if err != nil || remote == "" {
+		// Fall back to the first configured remote
+		if remotes, err := gitutils.Run(root, "remote"); err == nil && remotes != "" {
+			name, _, _ := strings.Cut(remotes, "\n")
+			remote, _ = gitutils.Run(root, "remote", "get-url", name)
+		}
+	}
+
+	branch, err := gitutils.Run(root, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		branch = ""
+	}
+	return remote, branch
+}
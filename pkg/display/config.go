@@ -0,0 +1,154 @@
+package display
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gambitier/tag-manager/pkg/config"
+)
+
+// ConfigFileRecord is a configuration file and whether it exists
+type ConfigFileRecord struct {
+	Layer  string `json:"layer" yaml:"layer"`
+	Path   string `json:"path" yaml:"path"`
+	Exists bool   `json:"exists" yaml:"exists"`
+}
+
+// ConfigRecord is the machine readable representation of the effective
+// configuration. Field names are stable across releases.
+type ConfigRecord struct {
+	Files    []ConfigFileRecord        `json:"files" yaml:"files"`
+	Defaults DefaultsRecord            `json:"defaults" yaml:"defaults"`
+	Rules    []RuleRecord              `json:"rules" yaml:"rules"`
+	Packages []ConfiguredPackageRecord `json:"packages" yaml:"packages"`
+}
+
+// DefaultsRecord is the effective default configuration
+type DefaultsRecord struct {
+	TagFormat       string   `json:"tag_format" yaml:"tag_format"`
+	TagFormatSource string   `json:"tag_format_source" yaml:"tag_format_source"`
+	Remotes         []string `json:"remotes" yaml:"remotes"`
+	RemotesSource   string   `json:"remotes_source" yaml:"remotes_source"`
+	Bump            string   `json:"bump" yaml:"bump"`
+	TagMessage      string   `json:"tag_message" yaml:"tag_message"`
+}
+
+// RuleRecord is a package rule in the order rules are tried
+type RuleRecord struct {
+	Name       string   `json:"name" yaml:"name"`
+	Module     string   `json:"module" yaml:"module"`
+	Repository string   `json:"repository" yaml:"repository"`
+	Dir        string   `json:"dir" yaml:"dir"`
+	Regex      bool     `json:"regex" yaml:"regex"`
+	TagFormat  string   `json:"tag_format" yaml:"tag_format"`
+	Remotes    []string `json:"remotes" yaml:"remotes"`
+	Bump       string   `json:"bump" yaml:"bump"`
+	TagMessage string   `json:"tag_message" yaml:"tag_message"`
+	Source     string   `json:"source" yaml:"source"`
+}
+
+// ConfiguredPackageRecord is the effective configuration of a package entry
+type ConfiguredPackageRecord struct {
+	ModulePath  string   `json:"module_path" yaml:"module_path"`
+	TagFormat   string   `json:"tag_format" yaml:"tag_format"`
+	UseDefault  bool     `json:"use_default" yaml:"use_default"`
+	Remotes     []string `json:"remotes" yaml:"remotes"`
+	Bump        string   `json:"bump" yaml:"bump"`
+	TagMessage  string   `json:"tag_message" yaml:"tag_message"`
+	LastUpdated string   `json:"last_updated" yaml:"last_updated"`
+	Source      string   `json:"source" yaml:"source"`
+}
+
+// configPackageColumns are the columns of packages in csv and markdown output
+var configPackageColumns = []string{"module_path", "tag_format", "use_default", "remotes", "bump", "last_updated", "source"}
+
+// NewConfigRecord converts the effective configuration to its machine
+// readable form
+func NewConfigRecord(cfg *config.Config, files []ConfigFileRecord) ConfigRecord {
+	record := ConfigRecord{
+		Files: append([]ConfigFileRecord{}, files...),
+		Defaults: DefaultsRecord{
+			TagFormat:       cfg.Defaults.TagFormat,
+			TagFormatSource: string(cfg.Source("defaults.tag_format")),
+			Remotes:         cfg.GetRemotes(""),
+			RemotesSource:   string(cfg.Source("defaults.remotes")),
+			Bump:            cfg.GetBumpPolicy(""),
+			TagMessage:      cfg.GetTagMessage(""),
+		},
+		Rules:    []RuleRecord{},
+		Packages: []ConfiguredPackageRecord{},
+	}
+
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		record.Rules = append(record.Rules, RuleRecord{
+			Name:       rule.Name,
+			Module:     rule.Match.Module,
+			Repository: rule.Match.Repository,
+			Dir:        rule.Match.Dir,
+			Regex:      rule.Regex,
+			TagFormat:  rule.TagFormat,
+			Remotes:    nonNil(rule.Remotes),
+			Bump:       rule.Bump,
+			TagMessage: rule.TagMessage,
+			Source:     string(rule.Layer()),
+		})
+	}
+
+	modulePaths := make([]string, 0, len(cfg.Packages))
+	for modulePath := range cfg.Packages {
+		modulePaths = append(modulePaths, modulePath)
+	}
+	sort.Strings(modulePaths)
+	for _, modulePath := range modulePaths {
+		pkg := cfg.GetPackageConfig(modulePath)
+		record.Packages = append(record.Packages, ConfiguredPackageRecord{
+			ModulePath:  modulePath,
+			TagFormat:   pkg.TagFormat,
+			UseDefault:  pkg.UseDefault,
+			Remotes:     cfg.GetRemotes(modulePath),
+			Bump:        cfg.GetBumpPolicy(modulePath),
+			TagMessage:  cfg.GetTagMessage(modulePath),
+			LastUpdated: pkg.LastUpdated,
+			Source:      string(cfg.Source("packages." + modulePath)),
+		})
+	}
+
+	return record
+}
+
+// WriteConfig writes the effective configuration in a machine readable
+// format. CSV and Markdown contain the configured packages only.
+func WriteConfig(w io.Writer, record ConfigRecord, format Format) error {
+	switch format {
+	case FormatCSV, FormatMarkdown:
+		rows := make([][]string, 0, len(record.Packages))
+		for _, pkg := range record.Packages {
+			rows = append(rows, []string{
+				pkg.ModulePath,
+				pkg.TagFormat,
+				strconv.FormatBool(pkg.UseDefault),
+				strings.Join(pkg.Remotes, ","),
+				pkg.Bump,
+				pkg.LastUpdated,
+				pkg.Source,
+			})
+		}
+		if format == FormatCSV {
+			return WriteCSV(w, configPackageColumns, rows)
+		}
+		return WriteMarkdown(w, configPackageColumns, rows)
+	default:
+		return WriteData(w, record, format)
+	}
+}
+
+// nonNil returns an empty slice instead of nil so lists are never null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
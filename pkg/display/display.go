@@ -1,12 +1,16 @@
 package display
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/history"
 	"github.com/olekukonko/tablewriter"
 )
 
@@ -20,8 +24,28 @@ const (
 	Verbose
 )
 
+// Columns returns the fields shown by a display mode
+func (m DisplayMode) Columns() []discovery.Field {
+	names := []string{"name", "tag"}
+	if m == Verbose {
+		names = []string{"module", "name", "go", "repo", "tag"}
+	}
+
+	columns := make([]discovery.Field, len(names))
+	for i, name := range names {
+		columns[i], _ = discovery.LookupField(name)
+	}
+	return columns
+}
+
 // ShowPackageList displays a list of packages in a table format
 func ShowPackageList(packages []discovery.Package, mode DisplayMode) {
+	ShowPackageColumns(packages, mode.Columns())
+}
+
+// ShowPackageColumns displays a list of packages in a table with the given
+// columns. Details must be loaded if a column needs them.
+func ShowPackageColumns(packages []discovery.Package, columns []discovery.Field) {
 	if len(packages) == 0 {
 		color.Red("No Go packages found.")
 		return
@@ -30,45 +54,142 @@ func ShowPackageList(packages []discovery.Package, mode DisplayMode) {
 	// Create table
 	table := tablewriter.NewWriter(os.Stdout)
 
-	if mode == Verbose {
-		table.Header("#", "Module", "Package", "Go Version", "GitHub", "Latest Tag")
-	} else {
-		table.Header("#", "Package", "Latest Tag")
+	header := []any{"#"}
+	for _, column := range columns {
+		header = append(header, column.Header)
 	}
+	table.Header(header...)
 
 	// Add rows
-	for i, pkg := range packages {
-		// Handle empty values
-		goVersion := pkg.GoVersion
-		if goVersion == "" {
-			goVersion = "-"
+	for i := range packages {
+		row := []any{fmt.Sprintf("%d", i+1)}
+		for _, column := range columns {
+			row = append(row, columnValue(&packages[i], column))
 		}
+		table.Append(row...)
+	}
+
+	table.Render()
+}
 
-		github := pkg.GitHubRepo
-		if github == "" {
-			github = "-"
+// columnValue formats a package field for the table, handling empty values
+func columnValue(pkg *discovery.Package, column discovery.Field) string {
+	if column.Details && pkg.Details == nil {
+		return "-"
+	}
+	value := column.Value(pkg)
+	if value != "" {
+		return value
+	}
+	if column.Name == "tag" {
+		return "(no tags)"
+	}
+	return "-"
+}
+
+// ShowPackageListWithHeader displays a list of packages with the given
+// columns and a header
+func ShowPackageListWithHeader(packages []discovery.Package, columns []discovery.Field, searchPaths []string) {
+	color.Cyan("Discovered %d Go packages:", len(packages))
+	color.White("Search paths: %s", strings.Join(searchPaths, ", "))
+	color.White("")
+
+	ShowPackageColumns(packages, columns)
+}
+
+// ShowReleaseHistory displays the releases of a package in a table format
+func ShowReleaseHistory(releases []history.Release) {
+	if len(releases) == 0 {
+		color.Yellow("No releases found.")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Version", "Tag", "Date", "Tagger", "Commit", "Subject", "Commits", "Notes")
+
+	for _, release := range releases {
+		date := "-"
+		if !release.Tag.Date.IsZero() {
+			date = release.Tag.Date.Format("2006-01-02 15:04")
 		}
 
-		latestTag := pkg.LatestTag
-		if latestTag == "" {
-			latestTag = "(no tags)"
+		subject := release.Tag.Subject
+		if !release.Tag.Annotated {
+			subject = "(lightweight)"
 		}
 
-		if mode == Verbose {
-			table.Append(fmt.Sprintf("%d", i+1), pkg.ModulePath, pkg.PackageName, goVersion, github, latestTag)
-		} else {
-			table.Append(fmt.Sprintf("%d", i+1), pkg.PackageName, latestTag)
+		var notes []string
+		if release.Gap != "" {
+			notes = append(notes, release.Gap)
+		}
+		if release.CreatedAfter != "" {
+			notes = append(notes, fmt.Sprintf("created after %s", release.CreatedAfter))
 		}
+
+		table.Append(
+			release.Version.Version,
+			release.Tag.Name,
+			date,
+			valueOrDash(release.Tag.Tagger),
+			shortCommit(release.Tag.Commit),
+			valueOrDash(subject),
+			fmt.Sprintf("%d", release.CommitCount),
+			valueOrDash(strings.Join(notes, "; ")),
+		)
 	}
 
 	table.Render()
 }
 
-// ShowPackageListWithHeader displays a list of packages with a header
-func ShowPackageListWithHeader(packages []discovery.Package, mode DisplayMode, searchPaths []string) {
-	color.Cyan("Discovered %d Go packages:", len(packages))
-	color.White("Search paths: %s", strings.Join(searchPaths, ", "))
-	color.White("")
+// releaseJSON is the JSON representation of a release
+type releaseJSON struct {
+	Version      string    `json:"version"`
+	Tag          string    `json:"tag"`
+	Date         time.Time `json:"date"`
+	Tagger       string    `json:"tagger"`
+	Commit       string    `json:"commit"`
+	Annotated    bool      `json:"annotated"`
+	Subject      string    `json:"subject"`
+	CommitCount  int       `json:"commit_count"`
+	Gap          string    `json:"gap,omitempty"`
+	CreatedAfter string    `json:"created_after,omitempty"`
+}
+
+// WriteReleaseHistoryJSON writes the releases of a package as JSON
+func WriteReleaseHistoryJSON(w io.Writer, releases []history.Release) error {
+	out := make([]releaseJSON, 0, len(releases))
+	for _, release := range releases {
+		out = append(out, releaseJSON{
+			Version:      release.Version.Version,
+			Tag:          release.Tag.Name,
+			Date:         release.Tag.Date,
+			Tagger:       release.Tag.Tagger,
+			Commit:       release.Tag.Commit,
+			Annotated:    release.Tag.Annotated,
+			Subject:      release.Tag.Subject,
+			CommitCount:  release.CommitCount,
+			Gap:          release.Gap,
+			CreatedAfter: release.CreatedAfter,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
 
-	ShowPackageList(packages, mode)
+// valueOrDash returns "-" for empty values
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// shortCommit abbreviates a commit hash
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return valueOrDash(commit)
 }
@@ -0,0 +1,47 @@
+package display
+
+import (
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/groups"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+)
+
+// ShowVersionGroups displays the version groups with members, flagging
+// groups whose members' latest releases have drifted apart
+func ShowVersionGroups(versionGroups []groups.Group) {
+	var shown []groups.Group
+	for _, group := range versionGroups {
+		if len(group.Members) > 0 {
+			shown = append(shown, group)
+		}
+	}
+	if len(shown) == 0 {
+		return
+	}
+
+	color.White("")
+	color.Cyan("Version groups:")
+	for _, group := range shown {
+		latest := group.Latest()
+		if !group.Drifted() {
+			version := "(no tags)"
+			if latest != nil {
+				version = latest.Version
+			}
+			color.Green("  %s (%d packages): %s", group.Name, len(group.Members), version)
+			continue
+		}
+
+		color.Yellow("  %s (%d packages): drifted, highest %s", group.Name, len(group.Members), latest.Version)
+		for _, member := range group.Members {
+			switch {
+			case member.Version == nil:
+				color.Yellow("    %s: (no tags)", member.Package.ModulePath)
+			case tagutils.CompareVersions(member.Version, latest) < 0:
+				color.Yellow("    %s: %s (behind %s)", member.Package.ModulePath, member.Tag, latest.Version)
+			default:
+				color.White("    %s: %s", member.Package.ModulePath, member.Tag)
+			}
+		}
+	}
+}
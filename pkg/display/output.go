@@ -0,0 +1,241 @@
+package display
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format of list and config
+type Format string
+
+const (
+	// FormatTable is the colored table meant for terminals
+	FormatTable Format = "table"
+	// FormatJSON is an indented JSON array or object
+	FormatJSON Format = "json"
+	// FormatYAML is a YAML document
+	FormatYAML Format = "yaml"
+	// FormatCSV is comma separated values with a header row
+	FormatCSV Format = "csv"
+	// FormatMarkdown is a Markdown table
+	FormatMarkdown Format = "markdown"
+)
+
+// Formats are the supported output formats
+var Formats = []Format{FormatTable, FormatJSON, FormatYAML, FormatCSV, FormatMarkdown}
+
+// ParseFormat validates an output format name
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if Format(name) == format {
+			return format, nil
+		}
+	}
+
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		names[i] = string(format)
+	}
+	return "", fmt.Errorf("invalid output format %q: must be one of %s", name, strings.Join(names, ", "))
+}
+
+// PackageRecord is the machine readable representation of a package. Field
+// names are stable across releases.
+type PackageRecord struct {
+	ModulePath  string `json:"module_path" yaml:"module_path"`
+	PackageName string `json:"package_name" yaml:"package_name"`
+	Path        string `json:"path" yaml:"path"`
+	GoVersion   string `json:"go_version" yaml:"go_version"`
+	Repository  string `json:"repository" yaml:"repository"`
+	LatestTag   string `json:"latest_tag" yaml:"latest_tag"`
+	// LatestPrerelease is empty unless a pre-release is newer than LatestTag
+	LatestPrerelease string `json:"latest_prerelease" yaml:"latest_prerelease"`
+	Group            string `json:"group" yaml:"group"`
+	RepoRoot         string `json:"repo_root" yaml:"repo_root"`
+	Dir              string `json:"dir" yaml:"dir"`
+	TagDate          string `json:"tag_date" yaml:"tag_date"`
+	// UnreleasedCommits is -1 when unknown
+	UnreleasedCommits int `json:"unreleased_commits" yaml:"unreleased_commits"`
+}
+
+// packageColumns are the columns of packages in csv and markdown output
+var packageColumns = []string{"module_path", "package_name", "path", "go_version", "repository", "latest_tag"}
+
+// NewPackageRecord converts a discovered package to its machine readable
+// form. Details are included when they were loaded.
+func NewPackageRecord(pkg discovery.Package) PackageRecord {
+	record := PackageRecord{
+		ModulePath:        pkg.ModulePath,
+		PackageName:       pkg.PackageName,
+		Path:              pkg.Path,
+		GoVersion:         pkg.GoVersion,
+		Repository:        pkg.GitHubRepo,
+		LatestTag:         pkg.LatestTag,
+		LatestPrerelease:  pkg.LatestPrerelease,
+		Group:             pkg.Group,
+		UnreleasedCommits: -1,
+	}
+	if pkg.Details != nil {
+		record.RepoRoot = pkg.Details.RepoRoot
+		record.Dir = pkg.Details.Dir
+		if !pkg.Details.TagDate.IsZero() {
+			record.TagDate = pkg.Details.TagDate.Format(time.RFC3339)
+		}
+		record.UnreleasedCommits = pkg.Details.UnreleasedCommits
+	}
+	return record
+}
+
+// values returns the record's fields in the order of packageColumns
+func (r PackageRecord) values() []string {
+	return []string{r.ModulePath, r.PackageName, r.Path, r.GoVersion, r.Repository, r.LatestTag}
+}
+
+// WritePackages writes packages in a machine readable format. JSON and YAML
+// contain every field; CSV and Markdown contain the given columns, or the
+// basic fields when columns is empty.
+func WritePackages(w io.Writer, packages []discovery.Package, format Format, columns []discovery.Field) error {
+	switch format {
+	case FormatCSV, FormatMarkdown:
+		header := packageColumns
+		rows := make([][]string, 0, len(packages))
+		if len(columns) > 0 {
+			header = make([]string, len(columns))
+			for i, column := range columns {
+				header[i] = column.Name
+			}
+			if needsDetails(columns) {
+				discovery.LoadDetails(packages)
+			}
+		}
+		for i := range packages {
+			if len(columns) == 0 {
+				rows = append(rows, NewPackageRecord(packages[i]).values())
+				continue
+			}
+			row := make([]string, len(columns))
+			for j, column := range columns {
+				row[j] = column.Value(&packages[i])
+			}
+			rows = append(rows, row)
+		}
+		if format == FormatCSV {
+			return WriteCSV(w, header, rows)
+		}
+		return WriteMarkdown(w, header, rows)
+	default:
+		discovery.LoadDetails(packages)
+		records := make([]PackageRecord, 0, len(packages))
+		for _, pkg := range packages {
+			records = append(records, NewPackageRecord(pkg))
+		}
+		return WriteData(w, records, format)
+	}
+}
+
+// needsDetails reports whether any column needs discovery.LoadDetails
+func needsDetails(columns []discovery.Field) bool {
+	for _, column := range columns {
+		if column.Details {
+			return true
+		}
+	}
+	return false
+}
+
+// WritePackagesTemplate executes a text/template once per package, adding a
+// newline after each result that doesn't end with one. Details are loaded
+// when the template refers to them.
+func WritePackagesTemplate(w io.Writer, packages []discovery.Package, text string) error {
+	if strings.Contains(text, ".Details") {
+		discovery.LoadDetails(packages)
+	}
+
+	tmpl, err := template.New("package").Funcs(template.FuncMap{
+		"json": func(value any) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+		"join": strings.Join,
+	}).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	for _, pkg := range packages {
+		var out strings.Builder
+		if err := tmpl.Execute(&out, pkg); err != nil {
+			return fmt.Errorf("failed to execute template for %s: %w", pkg.ModulePath, err)
+		}
+		result := out.String()
+		if !strings.HasSuffix(result, "\n") {
+			result += "\n"
+		}
+		if _, err := io.WriteString(w, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteData writes a value as JSON or YAML
+func WriteData(w io.Writer, value any, format Format) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("output format %s is not supported here", format)
+}
+
+// WriteCSV writes rows as CSV with a header row
+func WriteCSV(w io.Writer, header []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// WriteMarkdown writes rows as a Markdown table
+func WriteMarkdown(w io.Writer, header []string, rows [][]string) error {
+	separators := make([]string, len(header))
+	for i := range separators {
+		separators[i] = "---"
+	}
+
+	lines := []string{markdownRow(header), markdownRow(separators)}
+	for _, row := range rows {
+		lines = append(lines, markdownRow(row))
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// markdownRow formats the cells of a Markdown table row, escaping pipes
+func markdownRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.ReplaceAll(cell, "|", `\|`)
+	}
+	return "| " + strings.Join(escaped, " | ") + " |"
+}
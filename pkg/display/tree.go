@@ -0,0 +1,151 @@
+package display
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+)
+
+// treeNode is a directory in the tree view, holding the module rooted there
+type treeNode struct {
+	name     string
+	pkg      *discovery.Package
+	children map[string]*treeNode
+}
+
+// ShowPackageTree displays packages grouped by git repository and then by
+// directory, so modules nested inside other modules are shown below them
+func ShowPackageTree(packages []discovery.Package) {
+	if len(packages) == 0 {
+		color.Red("No Go packages found.")
+		return
+	}
+
+	for i, repo := range discovery.GroupByRepository(packages) {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		if repo.Root == "" {
+			color.Cyan("(not in a git repository)")
+			for j := range repo.Packages {
+				fmt.Printf("  %s %s\n", repo.Packages[j].Path, moduleSummary(&repo.Packages[j]))
+			}
+			continue
+		}
+
+		remote := repo.Remote
+		if remote == "" {
+			remote = "no remote"
+		}
+		branch := repo.Branch
+		if branch == "" {
+			branch = "detached HEAD"
+		}
+		fmt.Printf("%s  %s\n", color.CyanString(repo.Root), color.WhiteString("[%s, %s]", remote, branch))
+
+		root := &treeNode{children: make(map[string]*treeNode)}
+		for j := range repo.Packages {
+			root.insert(&repo.Packages[j])
+		}
+		if root.pkg != nil {
+			fmt.Printf("%s %s\n", color.WhiteString("."), moduleSummary(root.pkg))
+		}
+		root.print("")
+	}
+}
+
+// insert adds a package at its directory relative to the repository root
+func (n *treeNode) insert(pkg *discovery.Package) {
+	dir := path.Clean(pkg.Details.Dir)
+	if dir == "." || dir == "" {
+		n.pkg = pkg
+		return
+	}
+
+	node := n
+	for _, part := range strings.Split(dir, "/") {
+		child, exists := node.children[part]
+		if !exists {
+			child = &treeNode{name: part, children: make(map[string]*treeNode)}
+			node.children[part] = child
+		}
+		node = child
+	}
+	node.pkg = pkg
+}
+
+// print prints the children of a node with box drawing prefixes. Chains of
+// directories without modules are collapsed into a single line.
+func (n *treeNode) print(prefix string) {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		child := n.children[name]
+		label := child.name
+		for child.pkg == nil && len(child.children) == 1 {
+			for _, only := range child.children {
+				child = only
+			}
+			label += "/" + child.name
+		}
+
+		branch, indent := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		if child.pkg != nil {
+			fmt.Printf("%s%s%s %s\n", prefix, branch, label, moduleSummary(child.pkg))
+		} else {
+			fmt.Printf("%s%s%s/\n", prefix, branch, label)
+		}
+		child.print(prefix + indent)
+	}
+}
+
+// moduleSummary formats the module path, latest tag and release status of a package
+func moduleSummary(pkg *discovery.Package) string {
+	tag := color.YellowString("(no tags)")
+	if pkg.LatestTag != "" {
+		tag = color.GreenString(pkg.LatestTag)
+	}
+
+	var status string
+	switch unreleased := pkg.Details.UnreleasedCommits; {
+	case unreleased < 0:
+		status = ""
+	case unreleased == 0 && pkg.LatestTag != "":
+		status = color.GreenString("up to date")
+	case pkg.LatestTag == "":
+		status = color.YellowString("unreleased, %s", pluralize(unreleased, "commit"))
+	default:
+		status = color.YellowString("%s since tag", pluralize(unreleased, "commit"))
+	}
+
+	if pkg.LatestPrerelease != "" {
+		tag += "  " + color.CyanString(pkg.LatestPrerelease)
+	}
+
+	summary := fmt.Sprintf("%s  %s", color.WhiteString(pkg.ModulePath), tag)
+	if status != "" {
+		summary += "  " + status
+	}
+	return summary
+}
+
+// pluralize formats a count with a singular or plural noun
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
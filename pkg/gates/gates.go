@@ -0,0 +1,119 @@
+package gates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/gambitier/tag-manager/pkg/config"
+)
+
+// Gate represents a single pre-tag quality check
+type Gate struct {
+	Name    string
+	Command string
+	Args    []string
+}
+
+// Result represents the outcome of running a gate
+type Result struct {
+	Gate     Gate
+	Passed   bool
+	Duration time.Duration
+	Err      error
+}
+
+// DefaultGates returns the built-in gates run before every tag
+func DefaultGates() []Gate {
+	return []Gate{
+		{Name: "go build", Command: "go", Args: []string{"build", "./..."}},
+		{Name: "go vet", Command: "go", Args: []string{"vet", "./..."}},
+		{Name: "go test", Command: "go", Args: []string{"test", "./..."}},
+	}
+}
+
+// ModTidyGate returns a gate that fails when go.mod or go.sum are not tidy
+func ModTidyGate() Gate {
+	return Gate{Name: "go mod tidy", Command: "go", Args: []string{"mod", "tidy", "-diff"}}
+}
+
+// CustomGate returns a gate running an arbitrary shell command
+func CustomGate(command string) Gate {
+	return Gate{Name: command, Command: "sh", Args: []string{"-c", command}}
+}
+
+// FromConfig builds the list of gates for a package from its configuration
+func FromConfig(cfg config.GateConfig) []Gate {
+	if cfg.Disabled {
+		return nil
+	}
+
+	var gates []Gate
+	if !cfg.SkipDefaults {
+		gates = append(gates, DefaultGates()...)
+	}
+	if cfg.ModTidyEnabled() {
+		gates = append(gates, ModTidyGate())
+	}
+	for _, command := range cfg.Commands {
+		gates = append(gates, CustomGate(command))
+	}
+
+	return gates
+}
+
+// Run executes each gate in dir, streaming its output to out.
+// Every gate runs even if an earlier one fails so the summary is complete.
+func Run(dir string, gates []Gate, timeout time.Duration, out io.Writer) []Result {
+	results := make([]Result, 0, len(gates))
+
+	for _, gate := range gates {
+		fmt.Fprintf(out, "\n--- %s ---\n", gate.Name)
+		results = append(results, runGate(dir, gate, timeout, out))
+	}
+
+	return results
+}
+
+// runGate executes a single gate with the given timeout
+func runGate(dir string, gate Gate, timeout time.Duration, out io.Writer) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, gate.Command, gate.Args...)
+	cmd.Dir = dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// Don't wait forever on children that keep the output open after a timeout
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result := Result{
+		Gate:     gate,
+		Passed:   err == nil,
+		Duration: time.Since(start),
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.Passed = false
+		result.Err = fmt.Errorf("timed out after %s", timeout)
+	} else if err != nil {
+		result.Err = err
+	}
+
+	return result
+}
+
+// AllPassed reports whether every gate passed
+func AllPassed(results []Result) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,329 @@
+package gitutils
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TagRef represents a git tag together with its metadata
+type TagRef struct {
+	Name      string
+	Commit    string
+	Date      time.Time
+	Tagger    string
+	Subject   string
+	Annotated bool
+}
+
+// tagRefFormat is the git for-each-ref format used by ListTags. Fields
+// prefixed with * refer to the commit an annotated tag points at.
+const tagRefFormat = "%(refname:short)%1f%(objecttype)%1f%(objectname)%1f%(*objectname)%1f" +
+	"%(creatordate:iso-strict)%1f%(taggername)%1f%(committername)%1f%(contents:subject)"
+
+// Run runs a git command in dir and returns its trimmed output
+func Run(dir string, args ...string) (string, error) {
+	return run(dir, nil, args...)
+}
+
+// run runs a git command in dir with extra environment variables
+func run(dir string, env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// RepoRoot returns the top-level directory of the repository containing dir
+func RepoRoot(dir string) (string, error) {
+	return Run(dir, "rev-parse", "--show-toplevel")
+}
+
+// CreateTag creates an annotated tag pointing at ref
+func CreateTag(dir, tag, ref, message string) error {
+	_, err := Run(dir, "tag", "-a", tag, ref, "-m", message)
+	return err
+}
+
+// CreateTagAt creates an annotated tag pointing at ref with date as its
+// tagger date
+func CreateTagAt(dir, tag, ref, message string, date time.Time) error {
+	_, err := run(dir, []string{"GIT_COMMITTER_DATE=" + date.Format(time.RFC3339)}, "tag", "-a", tag, ref, "-m", message)
+	return err
+}
+
+// TagAnnotation returns the message of an annotated tag without its signature
+func TagAnnotation(dir, tag string) (string, error) {
+	return Run(dir, "tag", "--list", "--format=%(contents:subject)%0a%0a%(contents:body)", tag)
+}
+
+// PushTag pushes a tag to a remote
+func PushTag(dir, remote, tag string) error {
+	_, err := Run(dir, "push", remote, "refs/tags/"+tag)
+	return err
+}
+
+// DeleteTag deletes a local tag
+func DeleteTag(dir, tag string) error {
+	_, err := Run(dir, "tag", "--delete", tag)
+	return err
+}
+
+// DeleteRemoteTag deletes a tag from a remote
+func DeleteRemoteTag(dir, remote, tag string) error {
+	_, err := Run(dir, "push", remote, "--delete", "refs/tags/"+tag)
+	return err
+}
+
+// TagExists reports whether a local tag exists
+func TagExists(dir, tag string) bool {
+	_, err := Run(dir, "rev-parse", "--verify", "--quiet", "refs/tags/"+tag)
+	return err == nil
+}
+
+// MergedTags returns the names of tags matching pattern that are reachable
+// from ref
+func MergedTags(dir, ref, pattern string) ([]string, error) {
+	return tagNames(dir, "--merged", ref, pattern)
+}
+
+// TagsAt returns the names of tags matching pattern that point at ref
+func TagsAt(dir, ref, pattern string) ([]string, error) {
+	return tagNames(dir, "--points-at", ref, pattern)
+}
+
+// tagNames lists the names of tags selected by a git tag filter option
+func tagNames(dir, option, ref, pattern string) ([]string, error) {
+	output, err := Run(dir, "tag", "--list", option, ref, pattern)
+	if err != nil || output == "" {
+		return nil, err
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// RemoteHasTag reports whether a remote has a tag
+func RemoteHasTag(dir, remote, tag string) (bool, error) {
+	output, err := Run(dir, "ls-remote", "--tags", remote, "refs/tags/"+tag)
+	if err != nil {
+		return false, err
+	}
+	return output != "", nil
+}
+
+// ResolveCommit returns the full hash of the commit ref points at
+func ResolveCommit(dir, ref string) (string, error) {
+	return Run(dir, "rev-parse", "--verify", ref+"^{commit}")
+}
+
+// Commit represents a commit listed by Log
+type Commit struct {
+	Hash      string
+	ShortHash string
+	Date      time.Time
+	Author    string
+	Subject   string
+}
+
+// Log returns up to limit commits reachable from ref, newest first. When
+// paths are given, only commits touching them are returned; paths are
+// relative to dir.
+func Log(dir, ref string, limit int, paths ...string) ([]Commit, error) {
+	args := []string{"log", fmt.Sprintf("--max-count=%d", limit), "--format=%H%x1f%h%x1f%cI%x1f%an%x1f%s", ref, "--"}
+	output, err := Run(dir, append(args, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		commit := Commit{Hash: fields[0], ShortHash: fields[1], Author: fields[3], Subject: fields[4]}
+		if date, err := time.Parse(time.RFC3339, fields[2]); err == nil {
+			commit.Date = date
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// IsAncestor reports whether commit is reachable from ref
+func IsAncestor(dir, commit, ref string) (bool, error) {
+	cmd := exec.Command("git", "merge-base", "--is-ancestor", commit, ref)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return true, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("git merge-base --is-ancestor %s %s: %s", commit, ref, strings.TrimSpace(string(output)))
+}
+
+// ShowFile returns the content of a file at commit; path is relative to dir
+func ShowFile(dir, commit, path string) (string, error) {
+	return Run(dir, "show", commit+":./"+path)
+}
+
+// DefaultBranch returns the default branch of a remote, e.g. origin/main
+func DefaultBranch(dir, remote string) (string, error) {
+	return Run(dir, "symbolic-ref", "--short", "refs/remotes/"+remote+"/HEAD")
+}
+
+// AddWorktree checks out commit in a new detached worktree at path
+func AddWorktree(dir, path, commit string) error {
+	_, err := Run(dir, "worktree", "add", "--detach", path, commit)
+	return err
+}
+
+// RemoveWorktree removes a worktree added with AddWorktree
+func RemoveWorktree(dir, path string) error {
+	_, err := Run(dir, "worktree", "remove", "--force", path)
+	return err
+}
+
+// ListTags returns the tags in the repository containing dir that match any
+// of the given glob patterns, or all tags if no pattern is given
+func ListTags(dir string, patterns ...string) ([]TagRef, error) {
+	args := []string{"for-each-ref", "--format=" + tagRefFormat}
+	if len(patterns) == 0 {
+		args = append(args, "refs/tags")
+	}
+	for _, pattern := range patterns {
+		args = append(args, "refs/tags/"+pattern)
+	}
+
+	output, err := Run(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []TagRef
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 8 {
+			continue
+		}
+
+		tag := TagRef{
+			Name:      fields[0],
+			Commit:    fields[2],
+			Tagger:    fields[6],
+			Annotated: fields[1] == "tag",
+		}
+		if tag.Annotated {
+			tag.Commit = fields[3]
+			tag.Tagger = fields[5]
+			tag.Subject = fields[7]
+		}
+		if date, err := time.Parse(time.RFC3339, fields[4]); err == nil {
+			tag.Date = date
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// ExtractTree writes the contents of path at ref into destDir, preserving the
+// directory layout relative to the repository root. It reads directly from
+// git objects so the working tree is left untouched.
+func ExtractTree(repoDir, ref, path, destDir string) error {
+	args := []string{"archive", "--format=tar", ref}
+	if path != "" && path != "." {
+		args = append(args, "--", filepath.ToSlash(path))
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoDir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to read git archive: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run git archive: %w", err)
+	}
+
+	extractErr := extractTar(stdout, destDir)
+	// Drain the rest of the stream so git can exit
+	io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git archive %s failed: %s", ref, strings.TrimSpace(stderr.String()))
+	}
+
+	return extractErr
+}
+
+// extractTar unpacks a tar stream into destDir
+func extractTar(r io.Reader, destDir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		target := filepath.Join(destDir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(destDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("archive entry %s escapes destination", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(file, tr); err != nil {
+				file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package groups
+
+import (
+	"fmt"
+
+	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+)
+
+// Member is a discovered package of a version group
+type Member struct {
+	Package discovery.Package
+	// Tag is the latest stable release tag of the package, empty when it
+	// has none
+	Tag string
+	// Version is the version of Tag, nil when the package has no release
+	Version *tagutils.TagInfo
+	// Versions are all released versions of the package, pre-releases included
+	Versions []*tagutils.TagInfo
+}
+
+// Group is a version group with its discovered members
+type Group struct {
+	Name    string
+	Members []Member
+}
+
+// Resolve returns the version groups of cfg with their members among
+// packages and the latest release of each member
+func Resolve(cfg *config.Config, packages []discovery.Package) ([]Group, error) {
+	groups := make([]Group, len(cfg.Groups))
+	index := make(map[string]int)
+	for i := range cfg.Groups {
+		groups[i].Name = cfg.Groups[i].Name
+		index[cfg.Groups[i].Name] = i
+	}
+
+	for _, pkg := range packages {
+		group := cfg.GroupOf(pkg.ModulePath)
+		if group == nil {
+			continue
+		}
+		member, err := latestRelease(cfg, pkg)
+		if err != nil {
+			return nil, err
+		}
+		i := index[group.Name]
+		groups[i].Members = append(groups[i].Members, member)
+	}
+	return groups, nil
+}
+
+// Find returns the version group of a module with its members among
+// packages, or nil when the module isn't in a group
+func Find(cfg *config.Config, packages []discovery.Package, modulePath string) (*Group, error) {
+	group := cfg.GroupOf(modulePath)
+	if group == nil {
+		return nil, nil
+	}
+	resolved, err := Resolve(cfg, packages)
+	if err != nil {
+		return nil, err
+	}
+	for i := range resolved {
+		if resolved[i].Name == group.Name {
+			return &resolved[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Latest returns the highest stable version released by a member, or nil
+// when no member has a release
+func (g *Group) Latest() *tagutils.TagInfo {
+	var latest *tagutils.TagInfo
+	for _, member := range g.Members {
+		if member.Version != nil && (latest == nil || tagutils.CompareVersions(member.Version, latest) > 0) {
+			latest = member.Version
+		}
+	}
+	return latest
+}
+
+// Versions returns the versions released by any member
+func (g *Group) Versions() []*tagutils.TagInfo {
+	var versions []*tagutils.TagInfo
+	for _, member := range g.Members {
+		versions = append(versions, member.Versions...)
+	}
+	return versions
+}
+
+// Drifted reports whether the latest releases of the members differ,
+// including members without a release while others have one
+func (g *Group) Drifted() bool {
+	for i := 1; i < len(g.Members); i++ {
+		first, member := g.Members[0].Version, g.Members[i].Version
+		if (first == nil) != (member == nil) || (first != nil && tagutils.CompareVersions(first, member) != 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// latestRelease finds the latest stable release of a package under its tag
+// format, along with all its released versions
+func latestRelease(cfg *config.Config, pkg discovery.Package) (Member, error) {
+	member := Member{Package: pkg}
+	format := cfg.GetPackageConfig(pkg.ModulePath).TagFormat
+	packageName := tagutils.ExtractPackageNameFromModule(pkg.ModulePath)
+	layout, err := cfg.GetCalverLayout(pkg.ModulePath)
+	if err != nil {
+		return member, fmt.Errorf("invalid version scheme of %s: %w", pkg.ModulePath, err)
+	}
+
+	tags, err := gitutils.ListTags(pkg.Path, tagutils.FormatGlob(format, packageName))
+	if err != nil {
+		return member, fmt.Errorf("failed to list tags of %s: %w", pkg.ModulePath, err)
+	}
+	for _, tag := range tags {
+		info, ok := tagutils.MatchTag(format, packageName, tag.Name)
+		if !ok {
+			continue
+		}
+		if layout != "" {
+			info.SetLayout(layout)
+		}
+		member.Versions = append(member.Versions, info)
+		if info.IsPrerelease() || (member.Version != nil && tagutils.CompareVersions(info, member.Version) <= 0) {
+			continue
+		}
+		member.Tag = tag.Name
+		member.Version = info
+	}
+	return member, nil
+}
@@ -0,0 +1,191 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+)
+
+// Release represents a single tagged release of a package
+type Release struct {
+	Tag         gitutils.TagRef
+	Version     *tagutils.TagInfo
+	CommitCount int
+	// Gap describes versions skipped since the previous release
+	Gap string
+	// CreatedAfter is the higher version that was tagged before this one
+	CreatedAfter string
+}
+
+// Options controls which releases are returned
+type Options struct {
+	// Since only includes releases tagged on or after this time
+	Since time.Time
+	// SinceVersion only includes releases newer than this version
+	SinceVersion *tagutils.TagInfo
+	// Limit is the maximum number of releases to return (0 for no limit)
+	Limit int
+}
+
+// Load returns the releases of the package in pkgDir whose tags match format,
+// newest version first
+func Load(pkgDir, format, packageName string, opts Options) ([]Release, error) {
+	tags, err := gitutils.ListTags(pkgDir, tagutils.FormatGlob(format, packageName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	var releases []Release
+	for _, tag := range tags {
+		if info, ok := tagutils.MatchTag(format, packageName, tag.Name); ok {
+			releases = append(releases, Release{Tag: tag, Version: info})
+		}
+	}
+
+	// Work oldest version first so each release can be compared with its predecessor
+	sort.Slice(releases, func(i, j int) bool {
+		return tagutils.CompareVersions(releases[i].Version, releases[j].Version) < 0
+	})
+
+	for i := range releases {
+		if i == 0 {
+			releases[i].CommitCount = countCommits(pkgDir, "", releases[i].Tag.Commit)
+			continue
+		}
+		prev := releases[i-1]
+		releases[i].CommitCount = countCommits(pkgDir, prev.Tag.Commit, releases[i].Tag.Commit)
+		releases[i].Gap = describeGap(prev.Version, releases[i].Version)
+	}
+	markOutOfOrder(releases)
+
+	// Newest version first
+	for i, j := 0, len(releases)-1; i < j; i, j = i+1, j-1 {
+		releases[i], releases[j] = releases[j], releases[i]
+	}
+
+	return filter(releases, opts), nil
+}
+
+// filter applies the since and limit options to releases
+func filter(releases []Release, opts Options) []Release {
+	var filtered []Release
+	for _, release := range releases {
+		if !opts.Since.IsZero() && release.Tag.Date.Before(opts.Since) {
+			continue
+		}
+		if opts.SinceVersion != nil && tagutils.CompareVersions(release.Version, opts.SinceVersion) <= 0 {
+			continue
+		}
+		filtered = append(filtered, release)
+		if opts.Limit > 0 && len(filtered) == opts.Limit {
+			break
+		}
+	}
+	return filtered
+}
+
+// Versions returns the versions of the package in pkgDir whose tags match
+// format, in no particular order
+func Versions(pkgDir, format, packageName string) ([]*tagutils.TagInfo, error) {
+	tags, err := gitutils.ListTags(pkgDir, tagutils.FormatGlob(format, packageName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	var versions []*tagutils.TagInfo
+	for _, tag := range tags {
+		if info, ok := tagutils.MatchTag(format, packageName, tag.Name); ok {
+			versions = append(versions, info)
+		}
+	}
+	return versions, nil
+}
+
+// LatestStableTag returns the tag of the highest final release of the
+// package in pkgDir whose tags match format, or "" when it has none
+func LatestStableTag(pkgDir, format, packageName string) (string, error) {
+	tags, err := gitutils.ListTags(pkgDir, tagutils.FormatGlob(format, packageName))
+	if err != nil {
+		return "", fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	var latest string
+	var latestVersion *tagutils.TagInfo
+	for _, tag := range tags {
+		info, ok := tagutils.MatchTag(format, packageName, tag.Name)
+		if !ok || info.IsPrerelease() {
+			continue
+		}
+		if latestVersion == nil || tagutils.CompareVersions(info, latestVersion) > 0 {
+			latest, latestVersion = tag.Name, info
+		}
+	}
+	return latest, nil
+}
+
+// CommitsSince counts the commits touching pkgDir since tag, or all commits
+// touching it when tag is empty
+func CommitsSince(pkgDir, tag string) (int, error) {
+	revRange := "HEAD"
+	if tag != "" {
+		revRange = tag + "..HEAD"
+	}
+	output, err := gitutils.Run(pkgDir, "rev-list", "--count", revRange, "--", ".")
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits since %s: %w", tag, err)
+	}
+	return strconv.Atoi(output)
+}
+
+// countCommits counts the commits touching pkgDir between two commits.
+// An empty from counts all commits reachable from to.
+func countCommits(pkgDir, from, to string) int {
+	revRange := to
+	if from != "" {
+		revRange = from + ".." + to
+	}
+
+	output, err := gitutils.Run(pkgDir, "rev-list", "--count", revRange, "--", ".")
+	if err != nil {
+		return 0
+	}
+	count, err := strconv.Atoi(output)
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// describeGap reports versions skipped between two consecutive releases.
+// Pre-releases count as the version they lead up to.
+func describeGap(prev, cur *tagutils.TagInfo) string {
+	switch {
+	case cur.Major == prev.Major && cur.Minor == prev.Minor && cur.Patch == prev.Patch:
+		return ""
+	case cur.Major == prev.Major && cur.Minor == prev.Minor && cur.Patch == prev.Patch+1:
+		return ""
+	case cur.Major == prev.Major && cur.Minor == prev.Minor+1 && cur.Patch == 0:
+		return ""
+	case cur.Major == prev.Major+1 && cur.Minor == 0 && cur.Patch == 0:
+		return ""
+	default:
+		return fmt.Sprintf("skipped from %s", prev.Version)
+	}
+}
+
+// markOutOfOrder flags releases that were tagged after a higher version.
+// releases must be sorted oldest version first.
+func markOutOfOrder(releases []Release) {
+	for i := range releases {
+		for j := len(releases) - 1; j > i; j-- {
+			if releases[j].Tag.Date.Before(releases[i].Tag.Date) {
+				releases[i].CreatedAfter = releases[j].Version.Version
+				break
+			}
+		}
+	}
+}
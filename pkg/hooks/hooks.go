@@ -0,0 +1,44 @@
+package hooks
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Release describes the release a hook runs for
+type Release struct {
+	ModulePath string
+	Path       string
+	Tag        string
+	Version    string
+}
+
+// Env returns the environment variables describing the release to hooks
+func (r Release) Env() []string {
+	return []string{
+		"TAG_MANAGER_MODULE=" + r.ModulePath,
+		"TAG_MANAGER_PATH=" + r.Path,
+		"TAG_MANAGER_TAG=" + r.Tag,
+		"TAG_MANAGER_VERSION=" + r.Version,
+	}
+}
+
+// Run runs hook commands through the shell in the package directory, in
+// order, stopping at the first failure
+func Run(commands []string, release Release, out io.Writer) error {
+	for _, command := range commands {
+		fmt.Fprintf(out, "$ %s\n", command)
+
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Dir = release.Path
+		cmd.Env = append(os.Environ(), release.Env()...)
+		cmd.Stdout = out
+		cmd.Stderr = out
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("hook %q failed: %w", command, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package interactive
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+)
+
+// ConfigurePackage lets the user review and change the tag format, remotes,
+// tag message, hooks and version scheme of a package. The settings are stored in cfg once
+// the user confirms them.
+func ConfigurePackage(p Prompter, cfg *config.Config, pkg discovery.Package) (*config.PackageConfig, error) {
+	current := cfg.GetPackageConfig(pkg.ModulePath)
+	inherited := cfg.Inherited(pkg.ModulePath)
+	// Edit the stored entry so overrides of this run aren't saved with it;
+	// inherited values are only shown
+	edited := cfg.StoredPackageConfig(pkg.ModulePath)
+	edited.ModulePath = pkg.ModulePath
+	if edited.UseDefault || edited.TagFormat == "" {
+		edited.TagFormat = inherited.TagFormat
+		edited.UseDefault = true
+	}
+
+	color.Cyan("\n=== Configure %s ===", pkg.ModulePath)
+	color.White("Path: %s", pkg.Path)
+
+	for {
+		showSettings(&edited, &inherited)
+
+		color.Cyan("\nChange:")
+		color.White("1. Tag format")
+		color.White("2. Remotes")
+		color.White("3. Tag message")
+		color.White("4. Pre-tag hooks")
+		color.White("5. Post-tag hooks")
+		color.White("6. Version scheme")
+		color.White("7. Save")
+		color.White("8. Cancel")
+
+		choice, err := selectOption(p, 1, 8)
+		if err != nil {
+			return nil, err
+		}
+
+		switch choice {
+		case 1:
+			err = editTagFormat(p, cfg, pkg, &edited, &inherited)
+		case 2:
+			err = editRemotes(p, &edited, &inherited)
+		case 3:
+			err = editTagMessage(p, &edited, &inherited)
+		case 4, 5:
+			err = editHooks(p, &edited, &inherited, choice == 4)
+		case 6:
+			edited.VersionScheme, err = selectVersionScheme(p, inherited.VersionScheme)
+		case 7:
+			saved, err := saveSettings(p, cfg, pkg, current.TagFormat, &edited)
+			if err != nil || saved {
+				return &edited, err
+			}
+		case 8:
+			return nil, fmt.Errorf("configuration cancelled")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// showSettings prints the settings being edited, marking inherited values
+func showSettings(edited, inherited *config.PackageConfig) {
+	label := func(own bool) string {
+		if own {
+			return ""
+		}
+		return " (inherited)"
+	}
+	list := func(values []string) string {
+		if len(values) == 0 {
+			return "none"
+		}
+		return strings.Join(values, "; ")
+	}
+	hooks := effectiveHooks(edited, inherited)
+
+	color.Cyan("\nCurrent settings:")
+	color.White("  Tag format: %s%s", edited.TagFormat, label(!edited.UseDefault))
+	if len(edited.Remotes) > 0 {
+		color.White("  Remotes: %s", strings.Join(edited.Remotes, ", "))
+	} else {
+		color.White("  Remotes: %s (inherited)", strings.Join(inherited.Remotes, ", "))
+	}
+	if edited.TagMessage != "" {
+		color.White("  Tag message: %s", edited.TagMessage)
+	} else {
+		color.White("  Tag message: %s (inherited)", inherited.TagMessage)
+	}
+	color.White("  Pre-tag hooks: %s%s", list(hooks.PreTag), label(edited.Hooks != nil))
+	color.White("  Post-tag hooks: %s%s", list(hooks.PostTag), label(edited.Hooks != nil))
+	if edited.VersionScheme != "" {
+		color.White("  Version scheme: %s", edited.VersionScheme)
+	} else {
+		color.White("  Version scheme: %s (inherited)", inherited.VersionScheme)
+	}
+}
+
+// effectiveHooks returns the package's own hooks, or the inherited ones
+func effectiveHooks(edited, inherited *config.PackageConfig) config.HookConfig {
+	if edited.Hooks != nil {
+		return *edited.Hooks
+	}
+	return *inherited.Hooks
+}
+
+// editTagFormat asks for the tag format of the package. Formats colliding
+// with other packages of its repository are refused.
+func editTagFormat(p Prompter, cfg *config.Config, pkg discovery.Package, edited, inherited *config.PackageConfig) error {
+	color.Cyan("\nTag Format Options:")
+	color.White("1. Use default format: %s", inherited.TagFormat)
+	color.White("2. Define custom format")
+
+	choice, err := selectOption(p, 1, 2)
+	if err != nil {
+		return err
+	}
+	format := inherited.TagFormat
+	if choice == 2 {
+		if format, err = getCustomTagFormat(p); err != nil {
+			return err
+		}
+	}
+
+	if tagFormatCollides(cfg, pkg, format) {
+		color.Yellow("Tag format left unchanged.")
+		return nil
+	}
+	edited.TagFormat = format
+	edited.UseDefault = choice == 1
+	return nil
+}
+
+// editRemotes asks for the remotes tags of the package are pushed to
+func editRemotes(p Prompter, edited, inherited *config.PackageConfig) error {
+	answer, err := AskForInput(p, fmt.Sprintf("Remotes, comma separated (empty for %s)", strings.Join(inherited.Remotes, ", ")))
+	if err != nil {
+		return err
+	}
+
+	edited.Remotes = nil
+	for _, remote := range strings.Split(answer, ",") {
+		if remote = strings.TrimSpace(remote); remote != "" {
+			edited.Remotes = append(edited.Remotes, remote)
+		}
+	}
+	return nil
+}
+
+// editTagMessage asks for the annotation template of the package's tags
+func editTagMessage(p Prompter, edited, inherited *config.PackageConfig) error {
+	color.Cyan("\nTag message placeholders:")
+	color.White("  {tag} - The created tag")
+	color.White("  {module} - Module path")
+	color.White("  {version} - Full version (e.g., v1.2.3)")
+	color.White("  {package-name}, {major}, {minor}, {patch} - As in tag formats")
+
+	answer, err := AskForInput(p, fmt.Sprintf("Tag message (empty for %q)", inherited.TagMessage))
+	if err != nil {
+		return err
+	}
+	edited.TagMessage = answer
+	return nil
+}
+
+// editHooks asks for the pre-tag or post-tag hook commands of the package
+func editHooks(p Prompter, edited, inherited *config.PackageConfig, preTag bool) error {
+	kind := "post-tag"
+	if preTag {
+		kind = "pre-tag"
+	}
+	color.Cyan("\nEnter %s hook commands, one per line, and an empty line to finish.", kind)
+	color.White("They run through the shell in the package directory with TAG_MANAGER_TAG and TAG_MANAGER_VERSION set.")
+
+	var commands []string
+	for {
+		command, err := AskForInput(p, fmt.Sprintf("Command %d", len(commands)+1))
+		if err != nil {
+			return err
+		}
+		if command == "" {
+			break
+		}
+		commands = append(commands, command)
+	}
+
+	hooks := effectiveHooks(edited, inherited)
+	if preTag {
+		hooks.PreTag = commands
+	} else {
+		hooks.PostTag = commands
+	}
+	edited.Hooks = &hooks
+	return nil
+}
+
+// saveSettings previews the edited settings and stores them in cfg after
+// confirmation. It reports whether they were saved.
+func saveSettings(p Prompter, cfg *config.Config, pkg discovery.Package, previousFormat string, edited *config.PackageConfig) (bool, error) {
+	inherited := cfg.Inherited(pkg.ModulePath)
+	scheme := edited.VersionScheme
+	if scheme == "" {
+		scheme = inherited.VersionScheme
+	}
+	example := showTagExample(edited.TagFormat, scheme, pkg)
+	color.Cyan("\nExample tag: %s", example)
+	warnModuleVersion(scheme, pkg)
+	message := edited.TagMessage
+	if message == "" {
+		message = inherited.TagMessage
+	}
+	exampleInfo, _ := tagutils.MatchTag(edited.TagFormat, tagutils.ExtractPackageNameFromModule(pkg.ModulePath), example)
+	if exampleInfo != nil {
+		if layout, err := tagutils.SchemeLayout(scheme); err == nil && layout != "" {
+			exampleInfo.SetLayout(layout)
+		}
+		color.Cyan("Example message: %s", tagutils.FormatTagMessage(message, example, pkg.ModulePath, *exampleInfo))
+	}
+
+	if edited.TagFormat != previousFormat {
+		warnUnmatchedTags(pkg, previousFormat, edited.TagFormat)
+	}
+
+	confirmed, err := AskForConfirmation(p, "Save this configuration?")
+	if err != nil || !confirmed {
+		return false, err
+	}
+	cfg.SetPackageConfig(pkg.ModulePath, *edited)
+	color.Green("Configuration of %s updated", pkg.ModulePath)
+	return true, nil
+}
+
+// warnUnmatchedTags warns about tags of the package created with the
+// previous format that the new format doesn't produce
+func warnUnmatchedTags(pkg discovery.Package, previousFormat, format string) {
+	packageName := tagutils.ExtractPackageNameFromModule(pkg.ModulePath)
+	tags, err := gitutils.ListTags(pkg.Path, tagutils.FormatGlob(previousFormat, packageName))
+	if err != nil {
+		return
+	}
+
+	var unmatched []string
+	for _, tag := range tags {
+		if _, ok := tagutils.MatchTag(previousFormat, packageName, tag.Name); !ok {
+			continue
+		}
+		if _, ok := tagutils.MatchTag(format, packageName, tag.Name); !ok {
+			unmatched = append(unmatched, tag.Name)
+		}
+	}
+	if len(unmatched) == 0 {
+		return
+	}
+
+	examples := unmatched
+	if len(examples) > 3 {
+		examples = examples[:3]
+	}
+	color.Yellow("Warning: %d existing tag(s) of this package don't match the new format (e.g. %s)", len(unmatched), strings.Join(examples, ", "))
+	color.Yellow("They won't show up in the release history of the package.")
+}
@@ -1,75 +1,115 @@
 package interactive
 
 import (
-	"bufio"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/gambitier/tag-manager/pkg/config"
 	"github.com/gambitier/tag-manager/pkg/discovery"
+	"github.com/gambitier/tag-manager/pkg/display"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/namespace"
 	"github.com/gambitier/tag-manager/pkg/tagutils"
 )
 
 // SetupPackageConfig interactively sets up configuration for a package
-func SetupPackageConfig(cfg *config.Config, pkg discovery.Package) (*config.PackageConfig, error) {
+func SetupPackageConfig(p Prompter, cfg *config.Config, pkg discovery.Package) (*config.PackageConfig, error) {
 	color.Cyan("\n=== Package Configuration Setup ===")
 	color.White("Package: %s", pkg.ModulePath)
 	color.White("Path: %s", pkg.Path)
 	color.White("Package Name: %s", pkg.PackageName)
 
 	// Check if package already has configuration
-	if existingConfig, exists := cfg.Packages[pkg.ModulePath]; exists {
+	if _, exists := cfg.Packages[pkg.ModulePath]; exists {
+		existingConfig := cfg.GetPackageConfig(pkg.ModulePath)
 		color.Green("Using existing configuration:")
 		color.White("  Tag Format: %s", existingConfig.TagFormat)
 		color.White("  Use Default: %t", existingConfig.UseDefault)
+		color.White("Change it with 'tag-manager configure %s' or update --reconfigure", pkg.PackageName)
 		color.White("")
 		return &existingConfig, nil
 	}
 
-	return configurePackage(cfg, pkg)
+	// Packages matching a rule are configured by it
+	if rule, index := cfg.MatchRule(pkg.ModulePath); rule != nil && rule.TagFormat != "" {
+		ruleConfig := cfg.GetPackageConfig(pkg.ModulePath)
+		color.Green("Using configuration of %s:", rule.Describe(index))
+		color.White("  Tag Format: %s", ruleConfig.TagFormat)
+		color.White("")
+		return &ruleConfig, nil
+	}
+
+	return configurePackage(p, cfg, pkg)
 }
 
 // configurePackage handles the interactive configuration process
-func configurePackage(cfg *config.Config, pkg discovery.Package) (*config.PackageConfig, error) {
+func configurePackage(p Prompter, cfg *config.Config, pkg discovery.Package) (*config.PackageConfig, error) {
 	pkgConfig := config.PackageConfig{
 		ModulePath: pkg.ModulePath,
 	}
 
-	// Ask if user wants to use default format
-	color.Cyan("\nTag Format Options:")
-	color.White("1. Use default format: %s", cfg.Defaults.TagFormat)
-	color.White("2. Define custom format")
-
-	choice, err := selectOption(1, 2)
-	if err != nil {
-		return nil, err
-	}
+	// Ask for a tag format until it doesn't collide with other packages
+	for {
+		// Ask if user wants to use default format
+		color.Cyan("\nTag Format Options:")
+		color.White("1. Use default format: %s", cfg.Defaults.TagFormat)
+		color.White("2. Define custom format")
 
-	if choice == 1 {
-		// Use default format
-		pkgConfig.TagFormat = cfg.Defaults.TagFormat
-		pkgConfig.UseDefault = true
-		color.Green("Using default tag format: %s", pkgConfig.TagFormat)
-	} else {
-		// Custom format
-		customFormat, err := getCustomTagFormat()
+		choice, err := selectOption(p, 1, 2)
 		if err != nil {
 			return nil, err
 		}
-		pkgConfig.TagFormat = customFormat
-		pkgConfig.UseDefault = false
+
+		if choice == 1 {
+			// Use default format
+			pkgConfig.TagFormat = cfg.Defaults.TagFormat
+			pkgConfig.UseDefault = true
+		} else {
+			// Custom format
+			customFormat, err := getCustomTagFormat(p)
+			if err != nil {
+				return nil, err
+			}
+			pkgConfig.TagFormat = customFormat
+			pkgConfig.UseDefault = false
+		}
+
+		if !tagFormatCollides(cfg, pkg, pkgConfig.TagFormat) {
+			break
+		}
+		color.Yellow("Choose a tag format that includes something unique to this package.")
+	}
+	if pkgConfig.UseDefault {
+		color.Green("Using default tag format: %s", pkgConfig.TagFormat)
+	} else {
 		color.Green("Using custom tag format: %s", pkgConfig.TagFormat)
 	}
 
+	// Ask how versions are numbered
+	inheritedScheme := cfg.Inherited(pkg.ModulePath).VersionScheme
+	var err error
+	pkgConfig.VersionScheme, err = selectVersionScheme(p, inheritedScheme)
+	if err != nil {
+		return nil, err
+	}
+
 	// Show example of how the tag will look
-	exampleTag := showTagExample(pkgConfig.TagFormat, pkg)
+	scheme := pkgConfig.VersionScheme
+	if scheme == "" {
+		scheme = inheritedScheme
+	}
+	exampleTag := showTagExample(pkgConfig.TagFormat, scheme, pkg)
 	color.Cyan("Example tag: %s", exampleTag)
+	warnModuleVersion(scheme, pkg)
 
 	// Confirm configuration
-	if !AskForConfirmation("Save this configuration?") {
+	confirmed, err := AskForConfirmation(p, "Save this configuration?")
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
 		return nil, fmt.Errorf("configuration cancelled")
 	}
 
@@ -79,8 +119,31 @@ func configurePackage(cfg *config.Config, pkg discovery.Package) (*config.Packag
 	return &pkgConfig, nil
 }
 
+// tagFormatCollides reports whether format would produce the same tags as
+// another package of pkg's repository, printing the collisions
+func tagFormatCollides(cfg *config.Config, pkg discovery.Package, format string) bool {
+	root, err := gitutils.RepoRoot(pkg.Path)
+	if err != nil {
+		return false
+	}
+	collisions, err := namespace.Check(cfg, root, pkg.ModulePath, format)
+	if err != nil {
+		color.Yellow("Warning: failed to check tag collisions: %v", err)
+		return false
+	}
+	if len(collisions) == 0 {
+		return false
+	}
+
+	color.Red("Tag format %s collides with other packages of the repository:", format)
+	for _, collision := range collisions {
+		color.Red("  • %s", collision)
+	}
+	return true
+}
+
 // getCustomTagFormat gets a custom tag format from user input
-func getCustomTagFormat() (string, error) {
+func getCustomTagFormat(p Prompter) (string, error) {
 	color.Cyan("\nCustom Tag Format Configuration:")
 	color.White("Available placeholders:")
 	color.White("  {package-name} - Package name")
@@ -88,39 +151,93 @@ func getCustomTagFormat() (string, error) {
 	color.White("  {minor} - Minor version number")
 	color.White("  {patch} - Patch version number")
 	color.White("  {version} - Full version (e.g., v1.2.3)")
+	color.White("  {calver} - Version without the leading v (e.g., 2026.10.1)")
 
 	color.Cyan("\nExamples:")
 	color.White("  {package-name}/v{major}.{minor}.{patch}")
 	color.White("  {package-name}-{major}.{minor}.{patch}")
 	color.White("  v{major}.{minor}.{patch}")
 
-	for {
-		color.Cyan("Enter your custom tag format: ")
-		reader := bufio.NewReader(os.Stdin)
-		input, err := reader.ReadString('\n')
-		if err != nil {
-			return "", fmt.Errorf("failed to read input: %w", err)
+	var format string
+	err := ask(p, "Enter your custom tag format: ", func(answer string) error {
+		if answer == "" {
+			return fmt.Errorf("tag format cannot be empty")
+		}
+		if err := tagutils.ValidateTagFormat(answer); err != nil {
+			return fmt.Errorf("invalid format: %w", err)
 		}
+		format = answer
+		return nil
+	})
+	return format, err
+}
+
+// selectVersionScheme asks how the versions of a package are numbered. It
+// returns an empty scheme when the package keeps the inherited one.
+func selectVersionScheme(p Prompter, inherited string) (string, error) {
+	color.Cyan("\nVersion Scheme Options:")
+	color.White("1. semver - Semantic versions (e.g., v1.2.3)")
+	color.White("2. calver - Calendar versions (e.g., v2026.10.1)")
+
+	choice, err := selectOption(p, 1, 2)
+	if err != nil {
+		return "", err
+	}
 
-		format := strings.TrimSpace(input)
-		if format == "" {
-			color.Red("Tag format cannot be empty. Please try again.")
-			continue
+	scheme := tagutils.SchemeSemver
+	if choice == 2 {
+		scheme, err = getCalverScheme(p)
+		if err != nil {
+			return "", err
 		}
+	}
+	if inherited == "" {
+		inherited = tagutils.SchemeSemver
+	}
+	if scheme == inherited {
+		return "", nil
+	}
+	return scheme, nil
+}
+
+// getCalverScheme asks for the layout of calendar versions
+func getCalverScheme(p Prompter) (string, error) {
+	color.Cyan("\nCalendar Version Layout:")
+	color.White("Three segments separated by dots:")
+	color.White("  YYYY, YY, 0Y - Year (2026, 26, 26)")
+	color.White("  MM, 0M - Month (1, 01)")
+	color.White("  WW, 0W - ISO week (1, 01)")
+	color.White("  DD, 0D - Day of the month (1, 01)")
+	color.White("  MICRO - Release of the period, from 0")
+
+	color.Cyan("\nExamples:")
+	color.White("  YYYY.MM.MICRO (e.g., 2026.10.0, 2026.10.1)")
+	color.White("  YY.0M.DD (e.g., 26.10.17)")
 
-		// Validate format
-		if err := tagutils.ValidateTagFormat(format); err != nil {
-			color.Red("Invalid format: %v", err)
-			color.Yellow("Please try again.")
-			continue
+	var layout string
+	err := ask(p, fmt.Sprintf("Enter the layout (empty for %s): ", tagutils.DefaultCalverLayout), func(answer string) error {
+		if answer == "" {
+			answer = tagutils.DefaultCalverLayout
 		}
+		if err := tagutils.ValidateCalverLayout(answer); err != nil {
+			return err
+		}
+		layout = answer
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
 
-		return format, nil
+	if layout == tagutils.DefaultCalverLayout {
+		return tagutils.SchemeCalver, nil
 	}
+	return tagutils.SchemeCalver + ":" + layout, nil
 }
 
-// showTagExample shows an example of how a tag will look
-func showTagExample(format string, pkg discovery.Package) string {
+// showTagExample shows an example of how a tag will look. Calendar versions
+// show the version released today.
+func showTagExample(format, scheme string, pkg discovery.Package) string {
 	packageName := tagutils.ExtractPackageNameFromModule(pkg.ModulePath)
 	exampleInfo := tagutils.TagInfo{
 		PackageName: packageName,
@@ -130,71 +247,223 @@ func showTagExample(format string, pkg discovery.Package) string {
 		Version:     "v1.2.3",
 	}
 
+	if layout, err := tagutils.SchemeLayout(scheme); err == nil && layout != "" {
+		if today, err := tagutils.CalculateNewVersion(&tagutils.TagInfo{PackageName: packageName, Layout: layout}, ""); err == nil {
+			exampleInfo = *today
+		}
+	}
+
 	return tagutils.FormatTag(format, exampleInfo)
 }
 
-// SelectPackage allows user to select a package from a list
-func SelectPackage(packages []discovery.Package) (*discovery.Package, error) {
+// warnModuleVersion warns when the calendar versions of scheme can't be
+// versions of the package's module for the go command
+func warnModuleVersion(scheme string, pkg discovery.Package) {
+	layout, err := tagutils.SchemeLayout(scheme)
+	if err != nil || layout == "" {
+		return
+	}
+	today, err := tagutils.CalculateNewVersion(&tagutils.TagInfo{Layout: layout}, "")
+	if err != nil {
+		return
+	}
+	if err := tagutils.CheckModuleVersion(pkg.ModulePath, today.Version); err != nil {
+		color.Yellow("Warning: go get can't fetch calendar versions such as %s of %s: %v", today.Version, pkg.ModulePath, err)
+	}
+}
+
+// SelectPackage allows user to select a package from a list. In a terminal
+// it shows a picker searching packages as the user types; otherwise the
+// packages are listed and selected by number, package name or module path,
+// or narrowed with a filter expression (see discovery.ParseFilter).
+func SelectPackage(p Prompter, packages []discovery.Package) (*discovery.Package, error) {
+	selected, err := selectPackages(p, packages, false)
+	if err != nil {
+		return nil, err
+	}
+	return &selected[0], nil
+}
+
+// SelectPackages is like SelectPackage, but allows selecting several packages
+// when the picker is shown
+func SelectPackages(p Prompter, packages []discovery.Package) ([]discovery.Package, error) {
+	return selectPackages(p, packages, true)
+}
+
+// selectPackages selects one or, with multi, several packages
+func selectPackages(p Prompter, packages []discovery.Package, multi bool) ([]discovery.Package, error) {
 	if len(packages) == 0 {
 		return nil, fmt.Errorf("no packages found")
 	}
 
-	// No need to display packages again - they're already shown in the table
-	selection, err := selectOption(1, len(packages))
+	if console, ok := p.(*Console); ok {
+		if tty, ok := console.terminal(); ok {
+			return pickPackages(tty, console.out, packages, multi)
+		}
+	}
+
+	selected, err := selectNumbered(p, packages)
 	if err != nil {
 		return nil, err
 	}
+	return []discovery.Package{*selected}, nil
+}
+
+// selectNumbered lists the packages and asks for one of them
+func selectNumbered(p Prompter, packages []discovery.Package) (*discovery.Package, error) {
+	color.Cyan("Available packages:")
+	display.ShowPackageList(packages, display.Compact)
+	color.White("")
+
+	choices := packages
+	for {
+		var selected *discovery.Package
+		var narrowed []discovery.Package
+		err := ask(p, "Select package (enter number, name, or a filter such as auth* or has-tag): ", func(answer string) error {
+			if answer == "" {
+				return fmt.Errorf("enter a number between 1 and %d, a package name or a filter", len(choices))
+			}
+			if selection, err := strconv.Atoi(answer); err == nil {
+				if selection < 1 || selection > len(choices) {
+					return fmt.Errorf("invalid selection, please choose a number between %d and %d", 1, len(choices))
+				}
+				selected = &choices[selection-1]
+				return nil
+			}
+
+			matched := packagesNamed(choices, answer)
+			if len(matched) == 0 {
+				filter, err := discovery.ParseFilter(answer)
+				if err != nil {
+					return err
+				}
+				matched = filter.Apply(choices)
+			}
+			switch len(matched) {
+			case 0:
+				return fmt.Errorf("no packages match %q", answer)
+			case 1:
+				selected = &matched[0]
+				color.Green("Selected %s", selected.ModulePath)
+			default:
+				narrowed = matched
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if selected != nil {
+			return selected, nil
+		}
+
+		choices = narrowed
+		display.ShowPackageList(choices, display.Compact)
+	}
+}
 
-	return &packages[selection-1], nil
+// packagesNamed returns the packages whose name or module path is name
+func packagesNamed(packages []discovery.Package, name string) []discovery.Package {
+	var matched []discovery.Package
+	for _, pkg := range packages {
+		if pkg.PackageName == name || pkg.ModulePath == name {
+			matched = append(matched, pkg)
+		}
+	}
+	return matched
 }
 
+// VersionExplicit is the version type selected when the user wants to enter
+// the new version instead of bumping the current one
+const VersionExplicit = "explicit"
+
 // SelectVersionType allows user to select a version type
-func SelectVersionType() (string, error) {
+func SelectVersionType(p Prompter) (string, error) {
 	color.Cyan("\nVersion types:")
 	color.White("1. major - Breaking changes (e.g., v1.2.3 → v2.0.0)")
 	color.White("2. minor - New features (e.g., v1.2.3 → v1.3.0)")
 	color.White("3. patch - Bug fixes (e.g., v1.2.3 → v1.2.4)")
+	color.White("4. explicit - Enter a specific version (e.g., v3.0.0)")
 
-	selection, err := selectOption(1, 3)
+	selection, err := selectOption(p, 1, 4)
 	if err != nil {
 		return "", err
 	}
 
-	versionTypes := []string{"major", "minor", "patch"}
+	versionTypes := []string{"major", "minor", "patch", VersionExplicit}
 	return versionTypes[selection-1], nil
 }
 
+// AskForVersion asks for a version until it parses and passes validate
+func AskForVersion(p Prompter, validate func(*tagutils.TagInfo) error) (*tagutils.TagInfo, error) {
+	var version *tagutils.TagInfo
+	err := ask(p, "New version (e.g., v3.0.0): ", func(answer string) error {
+		parsed, err := tagutils.ParseVersion(answer)
+		if err != nil {
+			return err
+		}
+		if err := validate(parsed); err != nil {
+			return err
+		}
+		version = parsed
+		return nil
+	})
+	return version, err
+}
+
 // selectOption handles generic option selection
-func selectOption(min, max int) (int, error) {
-	color.Cyan("Select option (enter number): ")
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return 0, fmt.Errorf("failed to read input: %w", err)
-	}
+func selectOption(p Prompter, min, max int) (int, error) {
+	var selection int
+	err := ask(p, "Select option (enter number): ", func(answer string) error {
+		number, err := strconv.Atoi(answer)
+		if err != nil {
+			return fmt.Errorf("invalid input %q, please enter a number", answer)
+		}
+		if number < min || number > max {
+			return fmt.Errorf("invalid selection, please choose a number between %d and %d", min, max)
+		}
+		selection = number
+		return nil
+	})
+	return selection, err
+}
 
-	input = strings.TrimSpace(input)
-	selection, err := strconv.Atoi(input)
-	if err != nil {
-		return 0, fmt.Errorf("invalid input, please enter a number: %w", err)
-	}
+// AskForConfirmation asks for yes/no confirmation; an empty answer is no
+func AskForConfirmation(p Prompter, prompt string) (bool, error) {
+	var confirmed bool
+	err := ask(p, prompt+" (y/N): ", func(answer string) error {
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			confirmed = true
+		case "", "n", "no":
+			confirmed = false
+		default:
+			return fmt.Errorf("please answer y or n")
+		}
+		return nil
+	})
+	return confirmed, err
+}
 
-	if selection < min || selection > max {
-		return 0, fmt.Errorf("invalid selection, please choose a number between %d and %d", min, max)
+// AskForInput asks for a line of free-form text
+func AskForInput(p Prompter, prompt string) (string, error) {
+	return p.Ask(prompt + ": ")
+}
+
+// SelectCommit allows user to select a commit from a list
+func SelectCommit(p Prompter, commits []gitutils.Commit) (*gitutils.Commit, error) {
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits found")
 	}
 
-	return selection, nil
-}
+	color.Cyan("\nRecent commits:")
+	for i, commit := range commits {
+		color.White("%2d. %s %s %s (%s)", i+1, commit.ShortHash, commit.Date.Format("2006-01-02"), commit.Subject, commit.Author)
+	}
 
-// AskForConfirmation asks for yes/no confirmation
-func AskForConfirmation(prompt string) bool {
-	color.Cyan("%s (y/N): ", prompt)
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
+	selection, err := selectOption(p, 1, len(commits))
 	if err != nil {
-		return false
+		return nil, err
 	}
-
-	response = strings.ToLower(strings.TrimSpace(response))
-	return response == "y" || response == "yes"
+	return &commits[selection-1], nil
 }
@@ -0,0 +1,332 @@
+package interactive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/gambitier/tag-manager/pkg/discovery"
+	"golang.org/x/term"
+)
+
+// pickerMaxRows is the maximum number of packages the picker shows at once
+const pickerMaxRows = 10
+
+// packagePicker is a terminal package picker with incremental fuzzy search
+type packagePicker struct {
+	packages []discovery.Package
+	multi    bool
+	out      io.Writer
+
+	query []rune
+	// matches are indexes into packages, best match first
+	matches []int
+	// cursor is the highlighted entry of matches, offset the first one shown
+	cursor int
+	offset int
+	// selected holds the indexes of packages toggled for multi-select
+	selected map[int]bool
+
+	rows  int
+	width int
+	// lines is the number of lines drawn by the last render
+	lines int
+}
+
+// pickPackages lets the user pick packages in the terminal tty, filtering
+// them by typing. Only one package is picked unless multi is set.
+func pickPackages(tty *os.File, out io.Writer, packages []discovery.Package, multi bool) ([]discovery.Package, error) {
+	// The preview shows unreleased commits
+	discovery.LoadDetails(packages)
+
+	fd := int(tty.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	p := &packagePicker{
+		packages: packages,
+		multi:    multi,
+		out:      out,
+		selected: make(map[int]bool),
+		rows:     pickerMaxRows,
+		width:    80,
+	}
+	if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		p.width = width
+		// Leave room for the search line, preview and help
+		p.rows = min(pickerMaxRows, max(height-9, 3))
+	}
+	p.update()
+
+	buf := make([]byte, 64)
+	for {
+		p.render()
+		n, err := tty.Read(buf)
+		if err != nil {
+			p.clear()
+			return nil, ErrCancelled
+		}
+
+		switch key := string(buf[:n]); key {
+		case "\x1b[A", "\x1bOA", "\x10": // up, ctrl-p
+			p.move(-1)
+		case "\x1b[B", "\x1bOB", "\x0e": // down, ctrl-n
+			p.move(1)
+		case "\x1b[5~": // page up
+			p.move(-p.rows)
+		case "\x1b[6~": // page down
+			p.move(p.rows)
+		case "\t":
+			if p.multi && len(p.matches) > 0 {
+				index := p.matches[p.cursor]
+				p.selected[index] = !p.selected[index]
+				p.move(1)
+			}
+		case "\r", "\n":
+			if picked := p.picked(); len(picked) > 0 {
+				p.clear()
+				for _, pkg := range picked {
+					color.New(color.FgGreen).Fprintf(p.out, "Selected %s\r\n", pkg.ModulePath)
+				}
+				return picked, nil
+			}
+		case "\x1b", "\x03": // escape, ctrl-c
+			p.clear()
+			return nil, ErrCancelled
+		case "\x04": // ctrl-d
+			if len(p.query) == 0 {
+				p.clear()
+				return nil, ErrCancelled
+			}
+		case "\x7f", "\x08": // backspace
+			if len(p.query) > 0 {
+				p.query = p.query[:len(p.query)-1]
+				p.update()
+			}
+		case "\x15": // ctrl-u
+			p.query = nil
+			p.update()
+		default:
+			if typed := printable(key); typed != "" {
+				p.query = append(p.query, []rune(typed)...)
+				p.update()
+			}
+		}
+	}
+}
+
+// printable returns the printable characters of typed input, ignoring
+// unhandled escape sequences
+func printable(key string) string {
+	if strings.HasPrefix(key, "\x1b") {
+		return ""
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPrint(r) {
+			return r
+		}
+		return -1
+	}, key)
+}
+
+// update recomputes the matches after the query changed
+func (p *packagePicker) update() {
+	query := strings.ToLower(string(p.query))
+	scores := make(map[int]int)
+	p.matches = p.matches[:0]
+	for i := range p.packages {
+		score, ok := packageScore(query, &p.packages[i])
+		if ok {
+			p.matches = append(p.matches, i)
+			scores[i] = score
+		}
+	}
+	sort.SliceStable(p.matches, func(a, b int) bool {
+		return scores[p.matches[a]] > scores[p.matches[b]]
+	})
+	p.cursor, p.offset = 0, 0
+}
+
+// move moves the cursor by delta entries, scrolling the list as needed
+func (p *packagePicker) move(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.cursor = min(max(p.cursor+delta, 0), len(p.matches)-1)
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+p.rows {
+		p.offset = p.cursor - p.rows + 1
+	}
+}
+
+// picked returns the toggled packages, or the highlighted one if none are
+func (p *packagePicker) picked() []discovery.Package {
+	var picked []discovery.Package
+	for i := range p.packages {
+		if p.selected[i] {
+			picked = append(picked, p.packages[i])
+		}
+	}
+	if len(picked) == 0 && len(p.matches) > 0 {
+		picked = append(picked, p.packages[p.matches[p.cursor]])
+	}
+	return picked
+}
+
+// render redraws the picker in place
+func (p *packagePicker) render() {
+	var lines []string
+	lines = append(lines, color.CyanString("Select package: ")+string(p.query))
+
+	nameWidth := 0
+	for _, index := range p.matches {
+		nameWidth = max(nameWidth, len(p.packages[index].PackageName))
+	}
+	end := min(p.offset+p.rows, len(p.matches))
+	for i := p.offset; i < end; i++ {
+		pkg := &p.packages[p.matches[i]]
+		line := "  "
+		if i == p.cursor {
+			line = "> "
+		}
+		if p.multi {
+			if p.selected[p.matches[i]] {
+				line += "[x] "
+			} else {
+				line += "[ ] "
+			}
+		}
+		line += fmt.Sprintf("%-*s  %s", nameWidth, pkg.PackageName, pkg.ModulePath)
+		line = truncate(line, p.width)
+		if i == p.cursor {
+			line = color.New(color.FgCyan, color.Bold).Sprint(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(p.matches) == 0 {
+		lines = append(lines, color.YellowString("  No packages match"))
+	} else {
+		lines = append(lines, color.WhiteString("  %d/%d packages", len(p.matches), len(p.packages)))
+	}
+
+	// Preview of the highlighted package
+	lines = append(lines, "")
+	if len(p.matches) > 0 {
+		lines = append(lines, preview(&p.packages[p.matches[p.cursor]], p.width)...)
+	}
+
+	help := "↑/↓ move  enter select  esc cancel"
+	if p.multi {
+		help = "↑/↓ move  tab toggle  enter select  esc cancel"
+	}
+	lines = append(lines, color.New(color.Faint).Sprint(help))
+
+	p.clear()
+	fmt.Fprint(p.out, strings.Join(lines, "\r\n"))
+	p.lines = len(lines)
+}
+
+// clear removes the lines drawn by the last render
+func (p *packagePicker) clear() {
+	if p.lines == 0 {
+		return
+	}
+	if p.lines > 1 {
+		fmt.Fprintf(p.out, "\x1b[%dA", p.lines-1)
+	}
+	fmt.Fprint(p.out, "\r\x1b[J")
+	p.lines = 0
+}
+
+// preview describes a package for the preview pane
+func preview(pkg *discovery.Package, width int) []string {
+	tag := pkg.LatestTag
+	if tag == "" {
+		tag = "(no tags)"
+	}
+	unreleased := "unknown"
+	if pkg.Details != nil {
+		if !pkg.Details.TagDate.IsZero() {
+			tag += " (" + pkg.Details.TagDate.Format("2006-01-02") + ")"
+		}
+		if pkg.Details.UnreleasedCommits >= 0 {
+			unreleased = fmt.Sprintf("%d", pkg.Details.UnreleasedCommits)
+		}
+	}
+
+	lines := []string{
+		"Latest tag: " + tag,
+		"Path: " + pkg.Path,
+		"Unreleased commits: " + unreleased,
+	}
+	for i, line := range lines {
+		lines[i] = color.WhiteString("  %s", truncate(line, width-2))
+	}
+	return lines
+}
+
+// truncate shortens a line to width characters so it doesn't wrap
+func truncate(line string, width int) string {
+	if width <= 1 || utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	return string([]rune(line)[:width-1]) + "…"
+}
+
+// packageScore scores how well a package matches a lowercase query, matching
+// the package name first and the module path otherwise
+func packageScore(query string, pkg *discovery.Package) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+	nameScore, nameOK := fuzzyScore(query, strings.ToLower(pkg.PackageName))
+	pathScore, pathOK := fuzzyScore(query, strings.ToLower(pkg.ModulePath))
+	switch {
+	case nameOK && pathOK:
+		return max(nameScore*2, pathScore), true
+	case nameOK:
+		return nameScore * 2, true
+	case pathOK:
+		return pathScore, true
+	}
+	return 0, false
+}
+
+// fuzzyScore reports whether the characters of query appear in order in
+// text. Consecutive characters and characters starting a word score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	score := 0
+	last := -2
+	runes := []rune(text)
+	i := 0
+	for _, q := range query {
+		for i < len(runes) && runes[i] != q {
+			i++
+		}
+		if i == len(runes) {
+			return 0, false
+		}
+
+		score++
+		if i == last+1 {
+			score += 4
+		}
+		if i == 0 || strings.ContainsRune("/-_.", runes[i-1]) {
+			score += 3
+		}
+		last = i
+		i++
+	}
+	// Prefer shorter texts among equal matches
+	return score*100 - len(runes), true
+}
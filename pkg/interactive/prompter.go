@@ -0,0 +1,97 @@
+package interactive
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// MaxAttempts is how often a question is asked before invalid answers give up
+const MaxAttempts = 3
+
+// ErrCancelled is returned when the input ends, e.g. with Ctrl-D, before a
+// question was answered
+var ErrCancelled = errors.New("cancelled")
+
+// Prompter asks the user questions
+type Prompter interface {
+	// Ask shows prompt and returns the answer without surrounding space. It
+	// returns ErrCancelled when the input ends.
+	Ask(prompt string) (string, error)
+	// Out is where prompts and messages about rejected answers are written
+	Out() io.Writer
+}
+
+// Console is a Prompter reading answers line by line from a stream. All
+// questions share one reader, so answers piped in ahead are not lost.
+type Console struct {
+	in     io.Reader
+	reader *bufio.Reader
+	out    io.Writer
+}
+
+// NewPrompter returns a Prompter reading answers from in and writing prompts to out
+func NewPrompter(in io.Reader, out io.Writer) *Console {
+	return &Console{in: in, reader: bufio.NewReader(in), out: out}
+}
+
+// Ask shows prompt and reads a line
+func (c *Console) Ask(prompt string) (string, error) {
+	color.New(color.FgCyan).Fprint(c.out, prompt)
+	line, err := c.reader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		// The last answer may lack a trailing newline
+		err = nil
+	}
+	if err == io.EOF {
+		fmt.Fprintln(c.out)
+		return "", ErrCancelled
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Out returns the writer prompts are written to
+func (c *Console) Out() io.Writer {
+	return c.out
+}
+
+// terminal returns the input when both it and standard output are
+// terminals, so the package picker can be shown instead of numbered prompts
+func (c *Console) terminal() (*os.File, bool) {
+	file, ok := c.in.(*os.File)
+	if !ok || c.reader.Buffered() > 0 {
+		return nil, false
+	}
+	if !term.IsTerminal(int(file.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil, false
+	}
+	return file, true
+}
+
+// ask repeats a question until accept takes the answer, giving up after
+// MaxAttempts rejected answers
+func ask(p Prompter, prompt string, accept func(answer string) error) error {
+	for attempt := 1; ; attempt++ {
+		answer, err := p.Ask(prompt)
+		if err != nil {
+			return err
+		}
+		err = accept(answer)
+		if err == nil {
+			return nil
+		}
+		if attempt == MaxAttempts {
+			return fmt.Errorf("no valid answer after %d attempts: %w", MaxAttempts, err)
+		}
+		color.New(color.FgRed).Fprintf(p.Out(), "%v\n", err)
+	}
+}
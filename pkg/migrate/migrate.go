@@ -0,0 +1,86 @@
+package migrate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+)
+
+// Mapping is a tag of a package and the tag of the same version in the target
+// format
+type Mapping struct {
+	Old gitutils.TagRef
+	New string
+	// Exists is set when the new tag already points at the commit of the old
+	// one, e.g. after an interrupted migration
+	Exists bool
+}
+
+// Plan maps the tags of a package produced by fromFormat to the tags toFormat
+// produces for the same versions, oldest version first. Tags matching the
+// glob of fromFormat that it didn't produce, such as snapshots, are returned
+// as skipped. layout is the calendar version layout of the package.
+func Plan(dir, fromFormat, toFormat, packageName, layout string) ([]Mapping, []string, error) {
+	tags, err := gitutils.ListTags(dir, tagutils.FormatGlob(fromFormat, packageName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	var mappings []Mapping
+	var versions []*tagutils.TagInfo
+	var skipped []string
+	claimed := map[string]string{}
+	for _, tag := range tags {
+		info, ok := tagutils.MatchTag(fromFormat, packageName, tag.Name)
+		if !ok {
+			skipped = append(skipped, tag.Name)
+			continue
+		}
+		if layout != "" {
+			info.SetLayout(layout)
+		}
+
+		mapping := Mapping{Old: tag, New: tagutils.FormatTag(toFormat, *info)}
+		if previous, ok := claimed[mapping.New]; ok {
+			return nil, nil, fmt.Errorf("tags %s and %s both map to %s", previous, tag.Name, mapping.New)
+		}
+		claimed[mapping.New] = tag.Name
+
+		if commit, err := gitutils.ResolveCommit(dir, mapping.New); err == nil {
+			if commit != tag.Commit {
+				return nil, nil, fmt.Errorf("tag %s already exists and points at %s, not at the commit of %s", mapping.New, commit, tag.Name)
+			}
+			mapping.Exists = true
+		}
+		mappings = append(mappings, mapping)
+		versions = append(versions, info)
+	}
+
+	// Sort by version, keeping mappings and versions in step
+	for i := 1; i < len(mappings); i++ {
+		for j := i; j > 0 && tagutils.CompareVersions(versions[j], versions[j-1]) < 0; j-- {
+			mappings[j], mappings[j-1] = mappings[j-1], mappings[j]
+			versions[j], versions[j-1] = versions[j-1], versions[j]
+		}
+	}
+	return mappings, skipped, nil
+}
+
+// Message returns the annotation of the new tag of a mapping: the annotation
+// of the old tag followed by where and when it was originally tagged.
+// Lightweight tags have no annotation, so only their commit date is kept.
+func Message(dir string, mapping Mapping) (string, error) {
+	old := mapping.Old
+	date := old.Date.Format(time.RFC3339)
+	if !old.Annotated {
+		return fmt.Sprintf("Migrated from %s, a lightweight tag of a commit by %s on %s", old.Name, old.Tagger, date), nil
+	}
+
+	annotation, err := gitutils.TagAnnotation(dir, old.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the annotation of %s: %w", old.Name, err)
+	}
+	return fmt.Sprintf("%s\n\nMigrated from %s, tagged by %s on %s", annotation, old.Name, old.Tagger, date), nil
+}
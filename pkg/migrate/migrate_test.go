@@ -0,0 +1,103 @@
+package migrate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gambitier/tag-manager/internal/gittest"
+)
+
+const (
+	fromFormat = "{package-name}-v{major}.{minor}.{patch}"
+	toFormat   = "{package-name}/v{major}.{minor}.{patch}"
+)
+
+func TestPlan(t *testing.T) {
+	dir, commits := gittest.NewRepo(t, "Initial commit", "Second commit")
+	gittest.Run(t, dir, "tag", "utils-v1.2.3", commits[0])
+	gittest.Run(t, dir, "tag", "-a", "-m", "Release candidate", "utils-v1.10.0-rc.1", commits[1])
+	gittest.Run(t, dir, "tag", "utils-v1.10.0", commits[1])
+	gittest.Run(t, dir, "tag", "utils-v1.2.x", commits[1])
+	gittest.Run(t, dir, "tag", "other-v9.0.0", commits[1])
+
+	mappings, skipped, err := Plan(dir, fromFormat, toFormat, "utils", "")
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	var got []string
+	for _, mapping := range mappings {
+		got = append(got, mapping.Old.Name+" -> "+mapping.New)
+		if mapping.Exists {
+			t.Errorf("%s is marked as existing", mapping.New)
+		}
+	}
+	want := []string{
+		"utils-v1.2.3 -> utils/v1.2.3",
+		"utils-v1.10.0-rc.1 -> utils/v1.10.0-rc.1",
+		"utils-v1.10.0 -> utils/v1.10.0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan() mappings = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(skipped, []string{"utils-v1.2.x"}) {
+		t.Errorf("Plan() skipped = %q, want [utils-v1.2.x]", skipped)
+	}
+}
+
+func TestPlanExistingTarget(t *testing.T) {
+	dir, commits := gittest.NewRepo(t, "Initial commit", "Second commit")
+	gittest.Run(t, dir, "tag", "utils-v1.2.3", commits[0])
+	gittest.Run(t, dir, "tag", "utils-v1.3.0", commits[1])
+
+	// A re-run after an interrupted migration finds the tags already created
+	gittest.Run(t, dir, "tag", "utils/v1.2.3", commits[0])
+	mappings, _, err := Plan(dir, fromFormat, toFormat, "utils", "")
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(mappings) != 2 || !mappings[0].Exists || mappings[1].Exists {
+		t.Errorf("Plan() = %+v, want only utils/v1.2.3 existing", mappings)
+	}
+
+	// A target tag on another commit is a different release
+	gittest.Run(t, dir, "tag", "utils/v1.3.0", commits[0])
+	_, _, err = Plan(dir, fromFormat, toFormat, "utils", "")
+	if err == nil || !strings.Contains(err.Error(), "tag utils/v1.3.0 already exists") {
+		t.Fatalf("Plan() error = %v, want utils/v1.3.0 already exists", err)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	dir, commits := gittest.NewRepo(t, "Initial commit", "Second commit")
+	gittest.Run(t, dir, "tag", "-a", "-m", "First release", "utils-v1.0.0", commits[0])
+	gittest.Run(t, dir, "tag", "utils-v1.1.0", commits[1])
+
+	mappings, _, err := Plan(dir, fromFormat, toFormat, "utils", "")
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(mappings) != 2 {
+		t.Fatalf("Plan() = %+v, want 2 mappings", mappings)
+	}
+
+	tests := []struct {
+		mapping Mapping
+		want    []string
+	}{
+		{mappings[0], []string{"First release\n\n", "Migrated from utils-v1.0.0, tagged by test on "}},
+		{mappings[1], []string{"Migrated from utils-v1.1.0, a lightweight tag of a commit by test on "}},
+	}
+	for _, test := range tests {
+		message, err := Message(dir, test.mapping)
+		if err != nil {
+			t.Fatalf("Message() error = %v", err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(message, want) {
+				t.Errorf("Message(%s) = %q, want it to contain %q", test.mapping.Old.Name, message, want)
+			}
+		}
+	}
+}
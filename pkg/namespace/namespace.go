@@ -0,0 +1,181 @@
+package namespace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gambitier/tag-manager/pkg/config"
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"github.com/gambitier/tag-manager/pkg/tagutils"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// Collision is a pair of modules of one repository whose tag formats produce
+// overlapping tags, so their release histories get mixed up
+type Collision struct {
+	// Repo is the root of the repository
+	Repo string
+	A    string
+	B    string
+	// Tag is an example tag claimed by both modules
+	Tag string
+}
+
+// String describes the collision
+func (c Collision) String() string {
+	return fmt.Sprintf("%s and %s both claim tags such as %s", c.A, c.B, c.Tag)
+}
+
+// tagged is a module with the tags its format produces
+type tagged struct {
+	path    string
+	pattern *regexp.Regexp
+	samples []string
+}
+
+// RepoModules returns the paths of the Go modules in the repository at root,
+// including modules that aren't committed yet
+func RepoModules(root string) ([]string, error) {
+	output, err := gitutils.Run(root, "ls-files", "--cached", "--others", "--exclude-standard", "--", "go.mod", "*/go.mod")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list modules of %s: %w", root, err)
+	}
+
+	var paths []string
+	for _, file := range strings.Split(output, "\n") {
+		if file == "" || ignoredDir(filepath.Dir(file)) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil {
+			continue
+		}
+		if modulePath := modfile.ModulePath(data); modulePath != "" && !slices.Contains(paths, modulePath) {
+			paths = append(paths, modulePath)
+		}
+	}
+	return paths, nil
+}
+
+// ignoredDir reports whether the go command ignores modules in dir
+func ignoredDir(dir string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(dir), "/") {
+		if part == "testdata" || part == "vendor" || (strings.HasPrefix(part, ".") && part != ".") || strings.HasPrefix(part, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the collisions among the modules of the repository at root
+// under their configured tag formats
+func Find(cfg *config.Config, root string) ([]Collision, error) {
+	paths, err := RepoModules(root)
+	if err != nil {
+		return nil, err
+	}
+	return find(root, paths, func(modulePath string) string {
+		return cfg.GetPackageConfig(modulePath).TagFormat
+	}), nil
+}
+
+// Check returns the collisions of a module of the repository at root with
+// the other modules if it used format
+func Check(cfg *config.Config, root, modulePath, format string) ([]Collision, error) {
+	paths, err := RepoModules(root)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(paths, modulePath) {
+		paths = append(paths, modulePath)
+	}
+
+	var collisions []Collision
+	for _, collision := range find(root, paths, func(path string) string {
+		if path == modulePath {
+			return format
+		}
+		return cfg.GetPackageConfig(path).TagFormat
+	}) {
+		if collision.A == modulePath || collision.B == modulePath {
+			collisions = append(collisions, collision)
+		}
+	}
+	return collisions, nil
+}
+
+// find returns the collisions among modules with the tag formats given by
+// formatOf. Major versions of one module share tags on purpose, as the go
+// command tells them apart by major version.
+func find(root string, paths []string, formatOf func(string) string) []Collision {
+	slices.Sort(paths)
+	modules := make([]tagged, 0, len(paths))
+	for _, path := range paths {
+		if t, ok := newTagged(path, formatOf(path)); ok {
+			modules = append(modules, t)
+		}
+	}
+
+	var collisions []Collision
+	for i := range modules {
+		for j := i + 1; j < len(modules); j++ {
+			if sameModule(modules[i].path, modules[j].path) {
+				continue
+			}
+			if tag, ok := overlap(modules[i], modules[j]); ok {
+				collisions = append(collisions, Collision{Repo: root, A: modules[i].path, B: modules[j].path, Tag: tag})
+			}
+		}
+	}
+	return collisions
+}
+
+// newTagged builds the tag pattern and sample tags of a module. The samples
+// are a release and a pre-release of the major version of its module path.
+func newTagged(modulePath, format string) (tagged, bool) {
+	packageName := tagutils.ExtractPackageNameFromModule(modulePath)
+	pattern, err := tagutils.FormatRegexp(format, packageName)
+	if err != nil {
+		return tagged{}, false
+	}
+
+	major := 1
+	if _, pathMajor, ok := module.SplitPathVersion(modulePath); ok && pathMajor != "" {
+		major, _ = strconv.Atoi(strings.TrimPrefix(module.PathMajorPrefix(pathMajor), "v"))
+	}
+	result := tagged{path: modulePath, pattern: pattern}
+	for _, version := range []string{fmt.Sprintf("v%d.2.3", major), fmt.Sprintf("v%d.2.3-rc.1", major)} {
+		info, err := tagutils.ParseVersion(version)
+		if err != nil {
+			continue
+		}
+		info.PackageName = packageName
+		result.samples = append(result.samples, tagutils.FormatTag(format, *info))
+	}
+	return result, true
+}
+
+// overlap returns a tag produced for one module that the other claims too
+func overlap(a, b tagged) (string, bool) {
+	for _, pair := range [][2]tagged{{a, b}, {b, a}} {
+		for _, tag := range pair[0].samples {
+			if pair[1].pattern.MatchString(tag) {
+				return tag, true
+			}
+		}
+	}
+	return "", false
+}
+
+// sameModule reports whether two module paths are major versions of one module
+func sameModule(a, b string) bool {
+	prefixA, _, okA := module.SplitPathVersion(a)
+	prefixB, _, okB := module.SplitPathVersion(b)
+	return okA && okB && prefixA == prefixB
+}
@@ -0,0 +1,102 @@
+package pseudo
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gambitier/tag-manager/pkg/gitutils"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Version is the version the Go toolchain resolves a commit of a module to
+type Version struct {
+	// Commit is the full hash of the commit
+	Commit string
+	// Time is the commit time in UTC
+	Time time.Time
+	// Tag is the release tag pointing at the commit, empty when the commit
+	// resolves to a pseudo-version
+	Tag string
+	// BaseTag is the tag the pseudo-version builds on, empty when no tag of
+	// the module is reachable from the commit
+	BaseTag string
+	// Version is the release or pseudo-version
+	Version string
+}
+
+// TagPrefix returns the prefix of the tags the Go toolchain reads versions of
+// a module from: dir, the module's directory relative to the repository root,
+// without the major version subdirectory of modulePath
+func TagPrefix(dir, modulePath string) string {
+	dir = path.Clean(dir)
+	if _, pathMajor, ok := module.SplitPathVersion(modulePath); ok && strings.HasPrefix(pathMajor, "/") {
+		if dir == pathMajor[1:] {
+			dir = "."
+		}
+		dir = strings.TrimSuffix(dir, pathMajor)
+	}
+	if dir == "." || dir == "" {
+		return ""
+	}
+	return dir + "/"
+}
+
+// Compute returns the version of ref for the module in pkgDir whose directory
+// relative to the repository root is dir. A commit tagged with a release of
+// the module resolves to it; otherwise the pseudo-version builds on the
+// highest release reachable from the commit.
+func Compute(pkgDir, dir, modulePath, ref string) (*Version, error) {
+	_, pathMajor, ok := module.SplitPathVersion(modulePath)
+	if !ok {
+		return nil, fmt.Errorf("invalid module path %q", modulePath)
+	}
+
+	hash, err := gitutils.ResolveCommit(pkgDir, ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ref %q: no such commit", ref)
+	}
+	commits, err := gitutils.Log(pkgDir, hash, 1)
+	if err != nil || len(commits) == 0 {
+		return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+	result := &Version{Commit: hash, Time: commits[0].Date.UTC()}
+
+	prefix := TagPrefix(dir, modulePath)
+	tagged, err := gitutils.TagsAt(pkgDir, hash, prefix+"v*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", hash, err)
+	}
+	if tag, version := highest(tagged, prefix, pathMajor); tag != "" {
+		result.Tag, result.Version = tag, version
+		return result, nil
+	}
+
+	reachable, err := gitutils.MergedTags(pkgDir, hash, prefix+"v*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags reachable from %s: %w", hash, err)
+	}
+	base := ""
+	result.BaseTag, base = highest(reachable, prefix, pathMajor)
+	result.Version = module.PseudoVersion(module.PathMajorPrefix(pathMajor), base, result.Time, hash[:min(12, len(hash))])
+	return result, nil
+}
+
+// highest returns the tag with the highest version the Go toolchain accepts
+// for a module with the given major version suffix: canonical semantic
+// versions of that major that are not pseudo-versions themselves
+func highest(tags []string, prefix, pathMajor string) (string, string) {
+	var best, bestVersion string
+	for _, tag := range tags {
+		version := strings.TrimPrefix(tag, prefix)
+		if semver.Canonical(version) != version || module.IsPseudoVersion(version) || !module.MatchPathMajor(version, pathMajor) {
+			continue
+		}
+		if bestVersion == "" || semver.Compare(version, bestVersion) > 0 {
+			best, bestVersion = tag, version
+		}
+	}
+	return best, bestVersion
+}
@@ -0,0 +1,135 @@
+package pseudo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gambitier/tag-manager/internal/gittest"
+)
+
+// commitTime is the committer date of every commit in the test repositories
+const commitTime = "2026-10-18T12:00:00Z"
+
+// setupRepo creates a repository with two commits at commitTime and
+// returns its directory and the hashes of the commits, oldest first
+func setupRepo(t *testing.T) (string, []string) {
+	t.Setenv("GIT_AUTHOR_DATE", commitTime)
+	t.Setenv("GIT_COMMITTER_DATE", commitTime)
+	return gittest.NewRepo(t, "Initial commit", "Second commit")
+}
+
+func TestTagPrefix(t *testing.T) {
+	tests := []struct {
+		dir, modulePath, want string
+	}{
+		{".", "example.com/widget", ""},
+		{".", "example.com/widget/v2", ""},
+		{"v2", "example.com/widget/v2", ""},
+		{"lib", "example.com/widget/lib", "lib/"},
+		{"lib/v2", "example.com/widget/lib/v2", "lib/"},
+		{"lib", "example.com/widget/lib/v2", "lib/"},
+	}
+
+	for _, test := range tests {
+		if got := TagPrefix(test.dir, test.modulePath); got != test.want {
+			t.Errorf("TagPrefix(%q, %q) = %q, want %q", test.dir, test.modulePath, got, test.want)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name       string
+		dir        string
+		modulePath string
+		// tags are created on the first commit, headTags on the second
+		tags     []string
+		headTags []string
+		// want is the expected version, without the commit suffix of
+		// pseudo-versions
+		want        string
+		wantTag     string
+		wantBaseTag string
+	}{
+		{
+			name:       "no tag",
+			dir:        ".",
+			modulePath: "example.com/widget",
+			want:       "v0.0.0-20261018120000-",
+		},
+		{
+			name:        "release base",
+			dir:         ".",
+			modulePath:  "example.com/widget",
+			tags:        []string{"v1.2.2", "v1.2.3"},
+			want:        "v1.2.4-0.20261018120000-",
+			wantBaseTag: "v1.2.3",
+		},
+		{
+			name:        "pre-release base",
+			dir:         ".",
+			modulePath:  "example.com/widget",
+			tags:        []string{"v1.2.3", "v1.3.0-rc.1"},
+			want:        "v1.3.0-rc.1.0.20261018120000-",
+			wantBaseTag: "v1.3.0-rc.1",
+		},
+		{
+			name:        "major version subdirectory",
+			dir:         "lib/v2",
+			modulePath:  "example.com/widget/lib/v2",
+			tags:        []string{"lib/v1.5.0", "lib/v2.1.0", "v3.0.0"},
+			want:        "v2.1.1-0.20261018120000-",
+			wantBaseTag: "lib/v2.1.0",
+		},
+		{
+			name:       "tagged commit",
+			dir:        ".",
+			modulePath: "example.com/widget",
+			tags:       []string{"v1.2.3"},
+			headTags:   []string{"v1.2.4", "other/v9.0.0"},
+			want:       "v1.2.4",
+			wantTag:    "v1.2.4",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, commits := setupRepo(t)
+			for _, tag := range test.tags {
+				gittest.Run(t, dir, "tag", tag, commits[0])
+			}
+			for _, tag := range test.headTags {
+				gittest.Run(t, dir, "tag", tag, commits[1])
+			}
+
+			got, err := Compute(dir, test.dir, test.modulePath, "HEAD")
+			if err != nil {
+				t.Fatalf("Compute() error = %v", err)
+			}
+
+			want := test.want
+			if test.wantTag == "" {
+				want += commits[1][:12]
+			}
+			if got.Version != want {
+				t.Errorf("Version = %s, want %s", got.Version, want)
+			}
+			if got.Tag != test.wantTag {
+				t.Errorf("Tag = %q, want %q", got.Tag, test.wantTag)
+			}
+			if got.BaseTag != test.wantBaseTag {
+				t.Errorf("BaseTag = %q, want %q", got.BaseTag, test.wantBaseTag)
+			}
+			if got.Commit != commits[1] {
+				t.Errorf("Commit = %s, want %s", got.Commit, commits[1])
+			}
+		})
+	}
+}
+
+func TestComputeInvalidRef(t *testing.T) {
+	dir, _ := setupRepo(t)
+	if _, err := Compute(dir, ".", "example.com/widget", "no-such-ref"); err == nil || !strings.Contains(err.Error(), "no such commit") {
+		t.Fatalf("Compute() error = %v, want no such commit", err)
+	}
+}
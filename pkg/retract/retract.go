@@ -0,0 +1,97 @@
+package retract
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// Interval represents a retracted version or an inclusive range of versions
+type Interval struct {
+	Low  string
+	High string
+}
+
+// ParseInterval parses a single version (v1.2.3) or a range written as
+// [v1.2.0, v1.2.3] or v1.2.0..v1.2.3
+func ParseInterval(value string) (Interval, error) {
+	value = strings.TrimSpace(value)
+
+	var low, high string
+	switch {
+	case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+		parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"), ",")
+		if len(parts) != 2 {
+			return Interval{}, fmt.Errorf("invalid version range %q: expected [low, high]", value)
+		}
+		low, high = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	case strings.Contains(value, ".."):
+		parts := strings.SplitN(value, "..", 2)
+		low, high = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	default:
+		low, high = value, value
+	}
+
+	for _, version := range []string{low, high} {
+		if !semver.IsValid(version) {
+			return Interval{}, fmt.Errorf("invalid version %q: expected a semantic version such as v1.2.3", version)
+		}
+	}
+	if semver.Compare(low, high) > 0 {
+		return Interval{}, fmt.Errorf("invalid version range %q: %s is greater than %s", value, low, high)
+	}
+
+	return Interval{Low: low, High: high}, nil
+}
+
+// String formats the interval using go.mod syntax
+func (i Interval) String() string {
+	if i.Low == i.High {
+		return i.Low
+	}
+	return fmt.Sprintf("[%s, %s]", i.Low, i.High)
+}
+
+// Contains reports whether version falls inside the interval
+func (i Interval) Contains(version string) bool {
+	return semver.Compare(version, i.Low) >= 0 && semver.Compare(version, i.High) <= 0
+}
+
+// AddToGoMod adds a retract directive for interval to the go.mod file at
+// goModPath, with rationale written as its comment
+func AddToGoMod(goModPath string, interval Interval, rationale string) error {
+	info, err := os.Stat(goModPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	file, err := modfile.Parse(goModPath, data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+
+	for _, existing := range file.Retract {
+		if existing.Low == interval.Low && existing.High == interval.High {
+			return fmt.Errorf("%s is already retracted in go.mod", interval)
+		}
+	}
+
+	if err := file.AddRetract(modfile.VersionInterval{Low: interval.Low, High: interval.High}, rationale); err != nil {
+		return fmt.Errorf("failed to add retract directive: %w", err)
+	}
+	file.Cleanup()
+
+	if err := os.WriteFile(goModPath, modfile.Format(file.Syntax), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write go.mod: %w", err)
+	}
+
+	return nil
+}
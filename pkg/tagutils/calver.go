@@ -0,0 +1,160 @@
+package tagutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version schemes of a package
+const (
+	// SchemeSemver numbers versions as major.minor.patch
+	SchemeSemver = "semver"
+	// SchemeCalver numbers versions by release date, following a layout
+	SchemeCalver = "calver"
+)
+
+// DefaultCalverLayout is the layout of the calver scheme when none is given
+const DefaultCalverLayout = "YYYY.MM.MICRO"
+
+// calverTokens are the segments of a calendar version layout, by the unit
+// they count. A leading 0 zero-pads the value to two digits.
+var calverTokens = map[string]string{
+	"YYYY":  "year",
+	"YY":    "year",
+	"0Y":    "year",
+	"MM":    "month",
+	"0M":    "month",
+	"WW":    "week",
+	"0W":    "week",
+	"DD":    "day",
+	"0D":    "day",
+	"MICRO": "micro",
+}
+
+// SchemeLayout returns the calendar version layout of a version scheme, or
+// an empty string for semver. Schemes are semver, calver (with the default
+// layout) or calver:LAYOUT, e.g. calver:YY.0M.DD.
+func SchemeLayout(scheme string) (string, error) {
+	switch {
+	case scheme == "" || scheme == SchemeSemver:
+		return "", nil
+	case scheme == SchemeCalver:
+		return DefaultCalverLayout, nil
+	case strings.HasPrefix(scheme, SchemeCalver+":"):
+		layout := strings.TrimPrefix(scheme, SchemeCalver+":")
+		if err := ValidateCalverLayout(layout); err != nil {
+			return "", err
+		}
+		return layout, nil
+	}
+	return "", fmt.Errorf("invalid version scheme %q, expected %s, %s or %s:LAYOUT", scheme, SchemeSemver, SchemeCalver, SchemeCalver)
+}
+
+// ValidateCalverLayout checks a calendar version layout: three segments
+// separated by dots, starting with the year and optionally ending with MICRO
+func ValidateCalverLayout(layout string) error {
+	segments := strings.Split(layout, ".")
+	if len(segments) != 3 {
+		return fmt.Errorf("invalid calver layout %q: expected three segments such as %s", layout, DefaultCalverLayout)
+	}
+
+	units := make([]string, len(segments))
+	for i, segment := range segments {
+		unit, known := calverTokens[segment]
+		if !known {
+			return fmt.Errorf("invalid calver layout %q: unknown segment %q, expected YYYY, YY, 0Y, MM, 0M, WW, 0W, DD, 0D or MICRO", layout, segment)
+		}
+		units[i] = unit
+	}
+
+	switch {
+	case units[0] != "year":
+		return fmt.Errorf("invalid calver layout %q: the first segment must be the year", layout)
+	case units[1] != "month" && units[1] != "week":
+		return fmt.Errorf("invalid calver layout %q: the second segment must be the month or week", layout)
+	case units[2] == "day" && units[1] != "month":
+		return fmt.Errorf("invalid calver layout %q: a day follows the month", layout)
+	case units[2] != "day" && units[2] != "micro":
+		return fmt.Errorf("invalid calver layout %q: the last segment must be the day or MICRO", layout)
+	}
+	return nil
+}
+
+// formatCalver renders version numbers following a calendar version layout
+func formatCalver(layout string, numbers ...int) string {
+	segments := strings.Split(layout, ".")
+	parts := make([]string, len(numbers))
+	for i, number := range numbers {
+		parts[i] = formatSegment(segments, i, number)
+	}
+	return strings.Join(parts, ".")
+}
+
+// formatSegment renders the i-th version number, zero-padding it when the
+// matching layout segment asks for it
+func formatSegment(segments []string, i, number int) string {
+	if i < len(segments) && strings.HasPrefix(segments[i], "0") {
+		return fmt.Sprintf("%02d", number)
+	}
+	return strconv.Itoa(number)
+}
+
+// calverDate returns the value of a date segment of a layout for date.
+// Weekly layouts count years by ISO week, which differ around new year.
+func calverDate(segment string, date time.Time, weekly bool) int {
+	year, week := date.ISOWeek()
+	if !weekly {
+		year = date.Year()
+	}
+	switch segment {
+	case "YY", "0Y":
+		return year - 2000
+	case "MM", "0M":
+		return int(date.Month())
+	case "WW", "0W":
+		return week
+	case "DD", "0D":
+		return date.Day()
+	}
+	return year
+}
+
+// nextCalver returns the calendar version released on date after current.
+// MICRO counts the releases of a period, starting again at 0 when the
+// period rolls over; without it, one release per day is possible.
+func nextCalver(current *TagInfo, date time.Time) (*TagInfo, error) {
+	segments := strings.Split(current.Layout, ".")
+	if len(segments) != 3 {
+		return nil, fmt.Errorf("invalid calver layout %q", current.Layout)
+	}
+	weekly := calverTokens[segments[1]] == "week"
+
+	newVersion := *current
+	newVersion.Channel = ""
+	newVersion.ChannelNumber = 0
+	newVersion.Snapshot = ""
+	newVersion.Major = calverDate(segments[0], date, weekly)
+	newVersion.Minor = calverDate(segments[1], date, weekly)
+
+	period := CompareVersions(&TagInfo{Major: newVersion.Major, Minor: newVersion.Minor}, &TagInfo{Major: current.Major, Minor: current.Minor})
+	if segments[2] == "MICRO" {
+		switch {
+		case period > 0:
+			newVersion.Patch = 0
+		case period == 0:
+			newVersion.Patch = current.Patch + 1
+		default:
+			return nil, fmt.Errorf("current version %s is ahead of the date %s", current.Version, date.Format(time.DateOnly))
+		}
+	} else {
+		newVersion.Patch = calverDate(segments[2], date, weekly)
+		if CompareVersions(&newVersion, current) <= 0 {
+			return nil, fmt.Errorf("current version %s leaves no release for %s; layout %s has no MICRO segment", current.Version, date.Format(time.DateOnly), current.Layout)
+		}
+	}
+
+	newVersion.render()
+	return &newVersion, nil
+}
@@ -0,0 +1,102 @@
+package tagutils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// calver returns a calendar version with the given layout and numbers
+func calver(layout string, major, minor, patch int) *TagInfo {
+	version := &TagInfo{Major: major, Minor: minor, Patch: patch}
+	version.SetLayout(layout)
+	return version
+}
+
+func TestNextCalver(t *testing.T) {
+	tests := []struct {
+		name    string
+		current *TagInfo
+		date    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "same month increments micro",
+			current: calver("YYYY.MM.MICRO", 2026, 10, 3),
+			date:    "2026-10-18",
+			want:    "v2026.10.4",
+		},
+		{
+			name:    "month rollover resets micro",
+			current: calver("YYYY.MM.MICRO", 2026, 9, 5),
+			date:    "2026-10-01",
+			want:    "v2026.10.0",
+		},
+		{
+			name:    "year rollover resets micro",
+			current: calver("YYYY.MM.MICRO", 2025, 12, 2),
+			date:    "2026-01-05",
+			want:    "v2026.1.0",
+		},
+		{
+			name:    "zero-padded day layout",
+			current: calver("YY.0M.0D", 26, 1, 4),
+			date:    "2026-01-05",
+			want:    "v26.01.05",
+		},
+		{
+			name:    "short year with day",
+			current: calver("YY.0M.DD", 26, 10, 17),
+			date:    "2026-10-18",
+			want:    "v26.10.18",
+		},
+		{
+			name:    "second release of the day without micro",
+			current: calver("YY.0M.DD", 26, 10, 18),
+			date:    "2026-10-18",
+			wantErr: "has no MICRO segment",
+		},
+		{
+			name:    "ISO week year",
+			current: calver("YYYY.WW.MICRO", 2026, 52, 1),
+			date:    "2027-01-01",
+			want:    "v2026.53.0",
+		},
+		{
+			name:    "ahead of date",
+			current: calver("YYYY.MM.MICRO", 2026, 11, 0),
+			date:    "2026-10-18",
+			wantErr: "ahead of the date",
+		},
+		{
+			name:    "pre-release suffix is dropped",
+			current: &TagInfo{Major: 2026, Minor: 10, Patch: 1, Layout: "YYYY.MM.MICRO", Channel: ChannelRC, ChannelNumber: 1},
+			date:    "2026-10-18",
+			want:    "v2026.10.2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			date, err := time.Parse(time.DateOnly, test.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := nextCalver(test.current, date)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("nextCalver() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("nextCalver() error = %v", err)
+			}
+			if got.Version != test.want {
+				t.Errorf("nextCalver() = %s, want %s", got.Version, test.want)
+			}
+		})
+	}
+}
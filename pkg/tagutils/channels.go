@@ -0,0 +1,112 @@
+package tagutils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Release channels, from least to most stable. Releases on every channel but
+// final carry a pre-release suffix such as -rc.2.
+const (
+	ChannelAlpha = "alpha"
+	ChannelBeta  = "beta"
+	ChannelRC    = "rc"
+	ChannelFinal = "final"
+)
+
+// Channels lists the release channels from least to most stable
+var Channels = []string{ChannelAlpha, ChannelBeta, ChannelRC, ChannelFinal}
+
+// prereleasePattern matches the optional pre-release suffix of a version
+const prereleasePattern = `(?:-(?P<channel>alpha|beta|rc)\.(?P<number>\d+))?`
+
+// ValidateChannel checks that channel is a known release channel
+func ValidateChannel(channel string) error {
+	if ChannelRank(channel) < 0 {
+		return fmt.Errorf("invalid channel %q: must be one of %s", channel, strings.Join(Channels, ", "))
+	}
+	return nil
+}
+
+// ChannelRank returns the position of a channel from least to most stable,
+// or -1 for unknown channels. An empty channel is final.
+func ChannelRank(channel string) int {
+	if channel == "" {
+		channel = ChannelFinal
+	}
+	for i, known := range Channels {
+		if known == channel {
+			return i
+		}
+	}
+	return -1
+}
+
+// Prerelease returns the pre-release suffix of the version, e.g. -rc.2, or
+// an empty string for final releases
+func (t *TagInfo) Prerelease() string {
+	if t.Snapshot != "" {
+		return "-" + t.Snapshot
+	}
+	if t.Channel == "" {
+		return ""
+	}
+	return fmt.Sprintf("-%s.%d", t.Channel, t.ChannelNumber)
+}
+
+// IsPrerelease reports whether the version is a pre-release
+func (t *TagInfo) IsPrerelease() bool {
+	return t.Channel != "" || t.Snapshot != ""
+}
+
+// Final returns the final release the version leads up to
+func (t *TagInfo) Final() *TagInfo {
+	final := *t
+	final.Channel = ""
+	final.ChannelNumber = 0
+	final.Snapshot = ""
+	final.render()
+	return &final
+}
+
+// LatestStable returns the highest final release among versions, or nil
+func LatestStable(versions []*TagInfo) *TagInfo {
+	var latest *TagInfo
+	for _, version := range versions {
+		if !version.IsPrerelease() && (latest == nil || CompareVersions(version, latest) > 0) {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// InProgress returns the highest pre-release among versions that is newer
+// than every final release, or nil when no release cycle is in progress
+func InProgress(versions []*TagInfo) *TagInfo {
+	stable := LatestStable(versions)
+	var latest *TagInfo
+	for _, version := range versions {
+		if !version.IsPrerelease() || (stable != nil && CompareVersions(version, stable) < 0) {
+			continue
+		}
+		if latest == nil || CompareVersions(version, latest) > 0 {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// NextPrerelease returns the next pre-release of base on channel, numbered
+// after the pre-releases of base already released on that channel
+func NextPrerelease(versions []*TagInfo, base *TagInfo, channel string) *TagInfo {
+	next := base.Final()
+	next.Channel = channel
+	next.ChannelNumber = 1
+	for _, version := range versions {
+		if version.Channel == channel && CompareVersions(version.Final(), next.Final()) == 0 && version.ChannelNumber >= next.ChannelNumber {
+			next.ChannelNumber = version.ChannelNumber + 1
+		}
+	}
+	next.render()
+	return next
+}
@@ -0,0 +1,31 @@
+package tagutils
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// CheckModuleVersion reports why the go command can't use version as a
+// version of the module at modulePath: it must be a semantic version without
+// zero-padded numbers, and its major version must match the /vN suffix of
+// the module path
+func CheckModuleVersion(modulePath, version string) error {
+	if !semver.IsValid(version) {
+		return fmt.Errorf("%s is not a valid semantic version", version)
+	}
+	_, pathMajor, ok := module.SplitPathVersion(modulePath)
+	if !ok {
+		return fmt.Errorf("invalid module path %q", modulePath)
+	}
+	if err := module.CheckPathMajor(version, pathMajor); err != nil {
+		var versionErr *module.InvalidVersionError
+		if errors.As(err, &versionErr) {
+			err = versionErr.Err
+		}
+		return fmt.Errorf("version %s doesn't match module path %s: %w", version, modulePath, err)
+	}
+	return nil
+}
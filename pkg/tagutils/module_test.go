@@ -0,0 +1,30 @@
+package tagutils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckModuleVersion(t *testing.T) {
+	tests := []struct {
+		modulePath string
+		version    string
+		wantErr    string
+	}{
+		{modulePath: "example.com/lib", version: "v1.2.3"},
+		{modulePath: "example.com/lib/v26", version: "v26.10.0"},
+		{modulePath: "example.com/lib", version: "v26.10.0", wantErr: "should be v0 or v1, not v26"},
+		{modulePath: "example.com/lib/v2", version: "v26.10.0", wantErr: "should be v2, not v26"},
+		{modulePath: "example.com/lib/v26", version: "v26.01.05", wantErr: "not a valid semantic version"},
+	}
+
+	for _, test := range tests {
+		err := CheckModuleVersion(test.modulePath, test.version)
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("CheckModuleVersion(%s, %s) error = %v", test.modulePath, test.version, err)
+		case test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)):
+			t.Errorf("CheckModuleVersion(%s, %s) error = %v, want %q", test.modulePath, test.version, err, test.wantErr)
+		}
+	}
+}
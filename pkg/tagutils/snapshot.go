@@ -0,0 +1,56 @@
+package tagutils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// snapshotNamePattern matches the names snapshot pre-releases start with
+var snapshotNamePattern = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+// ValidateSnapshotName checks that name can start a snapshot pre-release
+// without being mistaken for a release channel
+func ValidateSnapshotName(name string) error {
+	if !snapshotNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid snapshot name %q: only letters, digits and hyphens are allowed", name)
+	}
+	if ChannelRank(name) >= 0 {
+		return fmt.Errorf("invalid snapshot name %q: %s are release channels", name, strings.Join(Channels, ", "))
+	}
+	return nil
+}
+
+// SnapshotSuffix builds the pre-release of a snapshot from its name, the time
+// formatted with a Go time layout and the commit hash abbreviated to
+// hashLength characters, separated by dots. An empty layout or a hashLength
+// of 0 leaves that part out.
+func SnapshotSuffix(name, layout string, at time.Time, commit string, hashLength int) string {
+	parts := []string{name}
+	if layout != "" {
+		parts = append(parts, at.Format(layout))
+	}
+	if hashLength > 0 {
+		parts = append(parts, commit[:min(hashLength, len(commit))])
+	}
+	return strings.Join(parts, ".")
+}
+
+// SetSnapshot turns the version into a snapshot with the given pre-release
+func (t *TagInfo) SetSnapshot(snapshot string) {
+	t.Channel = ""
+	t.ChannelNumber = 0
+	t.Snapshot = snapshot
+	t.render()
+}
+
+// MatchSnapshot parses a snapshot tag named name, returning false if the tag
+// is not such a snapshot produced by format for the given package name
+func MatchSnapshot(format, packageName, name, tag string) (*TagInfo, bool) {
+	re, err := formatRegexp(format, packageName, `-(?P<snapshot>`+regexp.QuoteMeta(name)+`(?:\.[0-9A-Za-z-]+)*)`)
+	if err != nil {
+		return nil, false
+	}
+	return matchRegexp(re, packageName, tag)
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // TagInfo represents tag information
@@ -14,64 +15,309 @@ type TagInfo struct {
 	Minor       int
 	Patch       int
 	Version     string
+	// Layout is the calendar version layout of the package, empty for semver
+	Layout string
+	// Channel is the pre-release channel (alpha, beta or rc), empty for
+	// final releases
+	Channel string
+	// ChannelNumber counts the pre-releases of the version on its channel
+	ChannelNumber int
+	// Snapshot is the pre-release of a snapshot, e.g. nightly.20261017.abc1234
+	Snapshot string
 }
 
-// FormatTag formats a tag according to the given format string
+// SetLayout sets the calendar version layout of the version, rendering its
+// numbers accordingly. An empty layout is semver.
+func (t *TagInfo) SetLayout(layout string) {
+	t.Layout = layout
+	t.render()
+}
+
+// render sets Version from the version numbers, layout and pre-release
+func (t *TagInfo) render() {
+	t.Version = "v" + formatCalver(t.Layout, t.Major, t.Minor, t.Patch) + t.Prerelease()
+}
+
+// FormatTag formats a tag according to the given format string. The
+// pre-release suffix follows {version} and {calver}, or {patch} in formats
+// without them.
 func FormatTag(format string, pkgInfo TagInfo) string {
 	tag := format
+	prerelease := pkgInfo.Prerelease()
+	patchSuffix := ""
+	if prereleasePlaceholder(format) == "{patch}" {
+		patchSuffix = prerelease
+	}
 
 	// Replace placeholders
 	tag = strings.ReplaceAll(tag, "{package-name}", pkgInfo.PackageName)
-	tag = strings.ReplaceAll(tag, "{major}", strconv.Itoa(pkgInfo.Major))
-	tag = strings.ReplaceAll(tag, "{minor}", strconv.Itoa(pkgInfo.Minor))
-	tag = strings.ReplaceAll(tag, "{patch}", strconv.Itoa(pkgInfo.Patch))
+	segments := strings.Split(pkgInfo.Layout, ".")
+	tag = strings.ReplaceAll(tag, "{major}", formatSegment(segments, 0, pkgInfo.Major))
+	tag = strings.ReplaceAll(tag, "{minor}", formatSegment(segments, 1, pkgInfo.Minor))
+	tag = strings.ReplaceAll(tag, "{patch}", formatSegment(segments, 2, pkgInfo.Patch)+patchSuffix)
 	tag = strings.ReplaceAll(tag, "{version}", pkgInfo.Version)
+	tag = strings.ReplaceAll(tag, "{calver}", formatCalver(pkgInfo.Layout, pkgInfo.Major, pkgInfo.Minor, pkgInfo.Patch)+prerelease)
 
 	return tag
 }
 
+// prereleasePlaceholder returns the placeholder of format followed by the
+// pre-release suffix
+func prereleasePlaceholder(format string) string {
+	for _, placeholder := range []string{"{version}", "{calver}"} {
+		if strings.Contains(format, placeholder) {
+			return placeholder
+		}
+	}
+	return "{patch}"
+}
+
+// FormatTagMessage formats the annotation of a tag from a message template.
+// Besides the placeholders of FormatTag, {tag} and {module} are replaced.
+func FormatTagMessage(template, tag, modulePath string, pkgInfo TagInfo) string {
+	message := strings.ReplaceAll(template, "{tag}", tag)
+	message = strings.ReplaceAll(message, "{module}", modulePath)
+	return FormatTag(message, pkgInfo)
+}
+
+// FormatRegexp builds a regular expression matching tags produced by format for
+// the given package name. The version numbers and pre-release are captured
+// in named groups.
+func FormatRegexp(format, packageName string) (*regexp.Regexp, error) {
+	return formatRegexp(format, packageName, prereleasePattern)
+}
+
+// formatRegexp builds the regular expression of FormatRegexp with suffix
+// matching the pre-release
+func formatRegexp(format, packageName, suffix string) (*regexp.Regexp, error) {
+	placeholders := map[string]string{
+		"{package-name}": regexp.QuoteMeta(packageName),
+		"{major}":        `(?P<major>\d+)`,
+		"{minor}":        `(?P<minor>\d+)`,
+		"{patch}":        `(?P<patch>\d+)`,
+		"{version}":      `v(?P<major>\d+)\.(?P<minor>\d+)\.(?P<patch>\d+)`,
+		"{calver}":       `(?P<major>\d+)\.(?P<minor>\d+)\.(?P<patch>\d+)`,
+	}
+	carrier := prereleasePlaceholder(format)
+	placeholders[carrier] += suffix
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	named := make(map[string]bool)
+	for rest := format; rest != ""; {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		pattern.WriteString(regexp.QuoteMeta(rest[:start]))
+		rest = rest[start:]
+
+		end := strings.Index(rest, "}")
+		placeholder := ""
+		if end >= 0 {
+			placeholder = rest[:end+1]
+		}
+		replacement, known := placeholders[placeholder]
+		if !known {
+			pattern.WriteString(regexp.QuoteMeta("{"))
+			rest = rest[1:]
+			continue
+		}
+
+		// A version number may appear more than once; later occurrences must
+		// repeat the same value, which RE2 can't express, so leave them unnamed
+		for _, name := range []string{"major", "minor", "patch", "channel", "number", "snapshot"} {
+			group := "?P<" + name + ">"
+			if strings.Contains(replacement, group) {
+				if named[name] {
+					replacement = strings.ReplaceAll(replacement, group, "")
+				}
+				named[name] = true
+			}
+		}
+		pattern.WriteString(replacement)
+		rest = rest[end+1:]
+	}
+	pattern.WriteString("$")
+
+	return regexp.Compile(pattern.String())
+}
+
+// MatchTag parses tag according to format, returning false if the tag was
+// not produced by format for the given package name
+func MatchTag(format, packageName, tag string) (*TagInfo, bool) {
+	re, err := FormatRegexp(format, packageName)
+	if err != nil {
+		return nil, false
+	}
+
+	return matchRegexp(re, packageName, tag)
+}
+
+// matchRegexp parses tag with a regular expression built by formatRegexp
+func matchRegexp(re *regexp.Regexp, packageName, tag string) (*TagInfo, bool) {
+	info, err := submatchVersion(re, tag)
+	if err != nil {
+		return nil, false
+	}
+	info.PackageName = packageName
+	return info, true
+}
+
+// submatchVersion builds the version captured by the named groups of re in
+// s: major, minor, patch and the optional channel and number
+func submatchVersion(re *regexp.Regexp, s string) (*TagInfo, error) {
+	matches := re.FindStringSubmatch(s)
+	if matches == nil {
+		return nil, fmt.Errorf("no version in %q", s)
+	}
+
+	info := &TagInfo{}
+	for i, name := range re.SubexpNames() {
+		if name == "" || matches[i] == "" {
+			continue
+		}
+		switch name {
+		case "name":
+			info.PackageName = matches[i]
+			continue
+		case "channel":
+			info.Channel = matches[i]
+			continue
+		case "snapshot":
+			info.Snapshot = matches[i]
+			continue
+		}
+		value, err := strconv.Atoi(matches[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version number in %q: %w", s, err)
+		}
+		switch name {
+		case "major":
+			info.Major = value
+		case "minor":
+			info.Minor = value
+		case "patch":
+			info.Patch = value
+		case "number":
+			info.ChannelNumber = value
+		}
+	}
+	info.render()
+	return info, nil
+}
+
+// TagPrefix returns the fixed part of the tags produced by format for the
+// given package name, up to the first version placeholder
+func TagPrefix(format, packageName string) string {
+	prefix := strings.ReplaceAll(format, "{package-name}", packageName)
+	for _, placeholder := range []string{"{major}", "{minor}", "{patch}", "{version}", "{calver}"} {
+		if index := strings.Index(prefix, placeholder); index >= 0 {
+			prefix = prefix[:index]
+		}
+	}
+	return prefix
+}
+
+// FormatGlob returns a git tag glob pattern matching tags produced by format
+// for the given package name
+func FormatGlob(format, packageName string) string {
+	glob := strings.ReplaceAll(format, "{package-name}", packageName)
+	glob = strings.ReplaceAll(glob, "{version}", "v*")
+	for _, placeholder := range []string{"{major}", "{minor}", "{patch}", "{calver}"} {
+		glob = strings.ReplaceAll(glob, placeholder, "*")
+	}
+	return glob
+}
+
+// CompareVersions compares two versions, returning -1, 0 or 1. Pre-releases
+// come before the final release, ordered by channel and then number.
+func CompareVersions(a, b *TagInfo) int {
+	for _, pair := range [][2]int{
+		{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch},
+		{ChannelRank(a.Channel), ChannelRank(b.Channel)}, {a.ChannelNumber, b.ChannelNumber},
+	} {
+		if pair[0] < pair[1] {
+			return -1
+		}
+		if pair[0] > pair[1] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// tagPatterns are the common tag formats recognized by ParseTag
+var tagPatterns = compileVersionPatterns(
+	`^(?P<name>.+)/v%s$`, // package/v1.2.3
+	`^v%s$`,              // v1.2.3
+	`^(?P<name>.+)-v%s$`, // package-v1.2.3
+	`^(?P<name>.+)-%s$`,  // package-1.2.3
+	`^(?P<name>.+)/%s$`,  // package/2026.10.1
+	`^%s$`,               // 2026.10.1
+)
+
+// versionPattern matches a version with an optional leading v
+var versionPattern = compileVersionPatterns(`^v?%s$`)[0]
+
+// compileVersionPatterns compiles patterns with %s standing for a version
+// with an optional pre-release suffix
+func compileVersionPatterns(patterns ...string) []*regexp.Regexp {
+	version := `(?P<major>\d+)\.(?P<minor>\d+)\.(?P<patch>\d+)` + prereleasePattern
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		compiled[i] = regexp.MustCompile(fmt.Sprintf(pattern, version))
+	}
+	return compiled
+}
+
 // ParseTag parses a tag string and extracts version information
 func ParseTag(tag string) (*TagInfo, error) {
 	// Try to match common tag formats
-	patterns := []string{
-		`^(.+)/v(\d+)\.(\d+)\.(\d+)$`, // package/v1.2.3
-		`^v(\d+)\.(\d+)\.(\d+)$`,      // v1.2.3
-		`^(.+)-v(\d+)\.(\d+)\.(\d+)$`, // package-v1.2.3
-		`^(.+)-(\d+)\.(\d+)\.(\d+)$`,  // package-1.2.3
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindStringSubmatch(tag)
-
-		if len(matches) >= 4 {
-			major, err1 := strconv.Atoi(matches[len(matches)-3])
-			minor, err2 := strconv.Atoi(matches[len(matches)-2])
-			patch, err3 := strconv.Atoi(matches[len(matches)-1])
-
-			if err1 == nil && err2 == nil && err3 == nil {
-				pkgName := ""
-				if len(matches) > 4 {
-					pkgName = matches[1]
-				}
-
-				return &TagInfo{
-					PackageName: pkgName,
-					Major:       major,
-					Minor:       minor,
-					Patch:       patch,
-					Version:     fmt.Sprintf("v%d.%d.%d", major, minor, patch),
-				}, nil
-			}
+	for _, re := range tagPatterns {
+		if info, err := submatchVersion(re, tag); err == nil {
+			return info, nil
 		}
 	}
 
 	return nil, fmt.Errorf("unable to parse tag: %s", tag)
 }
 
-// CalculateNewVersion calculates a new version based on the current version and version type
+// ParseVersion parses a version such as v1.2.3, 1.2.3 or v1.2.3-rc.1
+func ParseVersion(version string) (*TagInfo, error) {
+	if !versionPattern.MatchString(strings.TrimSpace(version)) {
+		return nil, fmt.Errorf("invalid version %q, expected vMAJOR.MINOR.PATCH with an optional -alpha.N, -beta.N or -rc.N", version)
+	}
+	return submatchVersion(versionPattern, strings.TrimSpace(version))
+}
+
+// VersionType returns the version type of the change from current to next:
+// the most significant component that increased, or an empty string when
+// next isn't greater than current
+func VersionType(current, next *TagInfo) string {
+	switch {
+	case CompareVersions(next, current) <= 0:
+		return ""
+	case next.Major != current.Major:
+		return "major"
+	case next.Minor != current.Minor:
+		return "minor"
+	}
+	return "patch"
+}
+
+// CalculateNewVersion calculates a new version based on the current version and version type.
+// Calendar versions ignore the version type and move to today's date in UTC.
 func CalculateNewVersion(current *TagInfo, versionType string) (*TagInfo, error) {
+	if current.Layout != "" {
+		return nextCalver(current, time.Now().UTC())
+	}
+
 	newVersion := *current
+	newVersion.Channel = ""
+	newVersion.ChannelNumber = 0
+	newVersion.Snapshot = ""
 
 	switch versionType {
 	case "major":
@@ -87,7 +333,7 @@ func CalculateNewVersion(current *TagInfo, versionType string) (*TagInfo, error)
 		return nil, fmt.Errorf("invalid version type: %s", versionType)
 	}
 
-	newVersion.Version = fmt.Sprintf("v%d.%d.%d", newVersion.Major, newVersion.Minor, newVersion.Patch)
+	newVersion.render()
 
 	return &newVersion, nil
 }
@@ -116,9 +362,9 @@ func ExtractPackageNameFromModule(modulePath string) string {
 
 // ValidateTagFormat validates a tag format string
 func ValidateTagFormat(format string) error {
-	// Check if format contains {version} (which is valid on its own)
-	if strings.Contains(format, "{version}") {
-		// {version} is valid on its own, no other placeholders required
+	// Check if format contains {version} or {calver} (which are valid on their own)
+	if strings.Contains(format, "{version}") || strings.Contains(format, "{calver}") {
+		// {version} and {calver} are valid on their own, no other placeholders required
 	} else {
 		// If not using {version}, check for required individual placeholders
 		required := []string{"{major}", "{minor}", "{patch}"}